@@ -49,3 +49,23 @@ func TestDsl(t *testing.T) {
 	emu.AssertLineEqual(t, 0, ">>> users")
 	emu.AssertLineEqual(t, 1, "      users    user table")
 }
+
+func TestCompletionDropdown(t *testing.T) {
+	emu := vtermtest.New(10, 80).
+		Command("go", "run", "./simple_example/main.go").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+
+	if err := emu.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer emu.Close()
+
+	// Wait for prompt to appear
+	emu.AssertScreenContains(t, ">>>")
+
+	if err := emu.KeyPressString("u<Tab>"); err != nil {
+		t.Fatal(err)
+	}
+
+	emu.AssertCompletions(t, 1, []string{"users", "users-1", "users-2"})
+}
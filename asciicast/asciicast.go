@@ -0,0 +1,77 @@
+// Package asciicast writes terminal sessions in the asciicast v2 format used by asciinema.
+// See https://docs.asciinema.org/manual/asciicast/v2/ for the format specification.
+package asciicast
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+	"unicode/utf8"
+)
+
+// Header is the first line of an asciicast v2 file.
+type Header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Encoder writes an asciicast v2 stream: a header line followed by one JSON
+// array per event.
+type Encoder struct {
+	w       io.Writer
+	pending []byte
+}
+
+// NewEncoder creates an Encoder writing to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// WriteHeader writes the header line. Version is always set to 2.
+func (enc *Encoder) WriteHeader(h Header) error {
+	h.Version = 2
+	return enc.writeLine(h)
+}
+
+// WriteOutput writes an output ("o") event at the given offset from the start of
+// the recording. A UTF-8 sequence split across calls is held back and emitted
+// with the next call, so every event contains valid text.
+func (enc *Encoder) WriteOutput(at time.Duration, data []byte) error {
+	return enc.writeEvent(at, "o", data)
+}
+
+func (enc *Encoder) writeEvent(at time.Duration, kind string, data []byte) error {
+	buf := append(enc.pending, data...)
+	enc.pending = nil
+
+	// Hold back an incomplete UTF-8 sequence at the end
+	cut := len(buf)
+	for i := len(buf) - 1; i >= 0 && i >= len(buf)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(buf[i]) {
+			if !utf8.FullRune(buf[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+	enc.pending = append([]byte(nil), buf[cut:]...)
+
+	if cut == 0 {
+		return nil
+	}
+	return enc.writeLine([]interface{}{at.Seconds(), kind, string(buf[:cut])})
+}
+
+func (enc *Encoder) writeLine(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	_, err = enc.w.Write(b)
+	return err
+}
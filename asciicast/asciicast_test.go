@@ -0,0 +1,39 @@
+package asciicast
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+
+	if err := enc.WriteHeader(Header{Width: 80, Height: 24, Env: map[string]string{"TERM": "xterm"}}); err != nil {
+		t.Fatalf("WriteHeader() error: %v", err)
+	}
+	if err := enc.WriteOutput(100*time.Millisecond, []byte("hello\r\n")); err != nil {
+		t.Fatalf("WriteOutput() error: %v", err)
+	}
+
+	// "日" is E6 97 A5; split it across two events
+	if err := enc.WriteOutput(200*time.Millisecond, []byte{'a', 0xE6, 0x97}); err != nil {
+		t.Fatalf("WriteOutput() error: %v", err)
+	}
+	if err := enc.WriteOutput(300*time.Millisecond, []byte{0xA5, 'b'}); err != nil {
+		t.Fatalf("WriteOutput() error: %v", err)
+	}
+
+	want := strings.Join([]string{
+		`{"version":2,"width":80,"height":24,"env":{"TERM":"xterm"}}`,
+		`[0.1,"o","hello\r\n"]`,
+		`[0.2,"o","a"]`,
+		`[0.3,"o","日b"]`,
+		``,
+	}, "\n")
+	if got := buf.String(); got != want {
+		t.Errorf("output mismatch:\nwant:\n%s\ngot:\n%s", want, got)
+	}
+}
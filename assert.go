@@ -2,8 +2,12 @@ package vtermtest
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
+
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/text/unicode/norm"
 )
 
 // Default retry configuration
@@ -20,15 +24,19 @@ type TestingT interface {
 }
 
 // AssertLineEqual asserts that a specific line equals the expected string.
+// Negative rows count from the bottom, so -1 is the last row (e.g. a status line).
 // It retries with exponential backoff until the assertion passes or max attempts is reached.
 func (e *Emulator) AssertLineEqual(t TestingT, row int, want string) {
 	t.Helper()
 	
+	want = e.normalize(want)
+	
 	e.assertWithRetry(t, func() error {
 		got, err := e.GetLine(row)
 		if err != nil {
 			return fmt.Errorf("failed to get line %d: %v", row, err)
 		}
+		got = e.normalize(got)
 		
 		if got != want {
 			return fmt.Errorf("line %d mismatch:\nwant: %q\ngot:  %q", row, want, got)
@@ -39,11 +47,13 @@ func (e *Emulator) AssertLineEqual(t TestingT, row int, want string) {
 
 // AssertScreenEqual asserts that the entire screen matches the expected string.
 // Leading/trailing whitespace in want is trimmed, and empty lines at the start are ignored.
+// On failure the rows are listed as a diff: matching rows prefixed with a space,
+// differing ones as the wanted row ('-') followed by the actual row ('+').
 func (e *Emulator) AssertScreenEqual(t TestingT, want string) {
 	t.Helper()
 	
 	// Normalize expected output
-	want = strings.TrimSpace(want)
+	want = e.normalize(strings.TrimSpace(want))
 	
 	e.assertWithRetry(t, func() error {
 		got, err := e.GetScreenText()
@@ -52,24 +62,70 @@ func (e *Emulator) AssertScreenEqual(t TestingT, want string) {
 		}
 		
 		// Normalize actual output
-		got = strings.TrimSpace(got)
+		got = e.normalize(strings.TrimSpace(got))
 		
 		if got != want {
-			return fmt.Errorf("screen mismatch:\n--- want ---\n%s\n--- got ---\n%s", want, got)
+			return fmt.Errorf("screen mismatch:\n%s\n%s", lineDiff(want, got), firstMismatch(want, got))
 		}
 		return nil
 	})
 }
 
+// AssertScreenOneOf asserts that the entire screen matches any of wants, for output
+// that legitimately varies across platforms or locales. Each variant is trimmed and
+// normalized like in AssertScreenEqual. On failure the variants tried are listed
+// with their number of differing cells, followed by the diff of the closest one.
+func (e *Emulator) AssertScreenOneOf(t TestingT, wants ...string) {
+	t.Helper()
+
+	if len(wants) == 0 {
+		t.Fatalf("AssertScreenOneOf requires at least one variant")
+		return
+	}
+
+	variants := make([]string, len(wants))
+	for i, want := range wants {
+		variants[i] = e.normalize(strings.TrimSpace(want))
+	}
+
+	e.assertWithRetry(t, func() error {
+		got, err := e.GetScreenText()
+		if err != nil {
+			return fmt.Errorf("failed to get screen: %v", err)
+		}
+		got = e.normalize(strings.TrimSpace(got))
+
+		closest, closestCells := 0, -1
+		var tried strings.Builder
+		for i, want := range variants {
+			if got == want {
+				return nil
+			}
+			cells := differingCells(want, got)
+			fmt.Fprintf(&tried, "\n  variant %d: %d cells differ", i, cells)
+			if closestCells < 0 || cells < closestCells {
+				closest, closestCells = i, cells
+			}
+		}
+
+		want := variants[closest]
+		return fmt.Errorf("screen matches none of %d variants:%s\nclosest is variant %d:\n%s\n%s",
+			len(variants), tried.String(), closest, lineDiff(want, got), firstMismatch(want, got))
+	})
+}
+
 // AssertScreenContains asserts that the screen contains the given substring.
 func (e *Emulator) AssertScreenContains(t TestingT, substr string) {
 	t.Helper()
 	
+	substr = e.normalize(substr)
+	
 	e.assertWithRetry(t, func() error {
 		got, err := e.GetScreenText()
 		if err != nil {
 			return fmt.Errorf("failed to get screen: %v", err)
 		}
+		got = e.normalize(got)
 		
 		if !strings.Contains(got, substr) {
 			return fmt.Errorf("screen does not contain %q:\n%s", substr, got)
@@ -78,6 +134,411 @@ func (e *Emulator) AssertScreenContains(t TestingT, substr string) {
 	})
 }
 
+// AssertScreenMatches asserts that the screen text matches the regular expression
+// pattern, for output with timestamps, PIDs or counts that vary between runs.
+// The pattern is matched in multi-line mode, so ^ and $ match at the start and
+// end of each row, e.g. `^\d+ rows in set`.
+func (e *Emulator) AssertScreenMatches(t TestingT, pattern string) {
+	t.Helper()
+
+	re, err := regexp.Compile("(?m)" + pattern)
+	if err != nil {
+		t.Fatalf("invalid pattern %q: %v", pattern, err)
+		return
+	}
+
+	e.assertWithRetry(t, func() error {
+		got, err := e.GetScreenText()
+		if err != nil {
+			return fmt.Errorf("failed to get screen: %v", err)
+		}
+		got = e.normalize(got)
+
+		if !re.MatchString(got) {
+			return fmt.Errorf("screen does not match %q:\n%s", pattern, got)
+		}
+		return nil
+	})
+}
+
+// AssertScreenNotContains asserts that the screen never shows the given substring,
+// e.g. a password being typed or an error message that was dismissed.
+//
+// Absence inverts the usual retry semantics: instead of stopping at the first
+// passing check, the screen is checked on every attempt of the retry window
+// (see WithAssertMaxAttempts), and the assertion fails as soon as substr appears
+// on any of them. It passes only after the whole window, so it takes as long as
+// a failing positive assertion would.
+func (e *Emulator) AssertScreenNotContains(t TestingT, substr string) {
+	t.Helper()
+
+	substr = e.normalize(substr)
+	maxAttempts := e.getMaxAttempts()
+	delay := e.getInitialDelay()
+	e.logAssert("AssertScreenNotContains: checking")
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		got, err := e.GetScreenText()
+		if err != nil {
+			t.Fatalf("failed to get screen: %v", err)
+			return
+		}
+		got = e.normalize(got)
+
+		if strings.Contains(got, substr) {
+			e.logAssert("AssertScreenNotContains: failed (attempt %d/%d)", attempt+1, maxAttempts)
+			t.Fatalf("screen contains %q (attempt %d/%d):\n%s", substr, attempt+1, maxAttempts, got)
+			return
+		}
+
+		if attempt < maxAttempts-1 {
+			time.Sleep(delay)
+			delay = time.Duration(float64(delay) * e.getBackoffFactor())
+		}
+	}
+	e.logAssert("AssertScreenNotContains: passed (%d attempts)", maxAttempts)
+}
+
+// AssertScreenFunc asserts that check returns nil for the current screen text.
+// The check is retried with exponential backoff, and the last error it returned is reported on failure.
+func (e *Emulator) AssertScreenFunc(t TestingT, check func(screen string) error) {
+	t.Helper()
+
+	e.assertWithRetry(t, func() error {
+		got, err := e.GetScreenText()
+		if err != nil {
+			return fmt.Errorf("failed to get screen: %v", err)
+		}
+		return check(e.normalize(got))
+	})
+}
+
+// AssertLineFunc asserts that check returns nil for the given line.
+// The check is retried with exponential backoff, and the last error it returned is reported on failure.
+func (e *Emulator) AssertLineFunc(t TestingT, row int, check func(line string) error) {
+	t.Helper()
+
+	e.assertWithRetry(t, func() error {
+		got, err := e.GetLine(row)
+		if err != nil {
+			return fmt.Errorf("failed to get line %d: %v", row, err)
+		}
+		if err := check(e.normalize(got)); err != nil {
+			return fmt.Errorf("line %d: %v", row, err)
+		}
+		return nil
+	})
+}
+
+// AssertCompletions asserts that the lines starting at startRow each contain the
+// corresponding suggestion in want, as rendered by completion dropdowns.
+// Suggestions are trimmed of surrounding spaces before matching.
+func (e *Emulator) AssertCompletions(t TestingT, startRow int, want []string) {
+	t.Helper()
+
+	e.assertWithRetry(t, func() error {
+		for i, suggestion := range want {
+			row := startRow + i
+			got, err := e.GetLine(row)
+			if err != nil {
+				return fmt.Errorf("failed to get line %d: %v", row, err)
+			}
+
+			suggestion = e.normalize(strings.TrimSpace(suggestion))
+			if !strings.Contains(e.normalize(got), suggestion) {
+				return fmt.Errorf("completion %d not found on line %d:\nwant: %q\ngot:  %q", i, row, suggestion, got)
+			}
+		}
+		return nil
+	})
+}
+
+// AssertMaxLineWidth asserts that no line on the screen is wider than width
+// display cells. Widths are runewidth-aware, so wide characters count as two cells.
+func (e *Emulator) AssertMaxLineWidth(t TestingT, width int) {
+	t.Helper()
+
+	e.assertWithRetry(t, func() error {
+		got, err := e.getScreenText()
+		if err != nil {
+			return fmt.Errorf("failed to get screen: %v", err)
+		}
+
+		for row, line := range strings.Split(got, "\n") {
+			if w := runewidth.StringWidth(line); w > width {
+				return fmt.Errorf("line %d is %d cells wide, max %d:\n%q", row, w, width, line)
+			}
+		}
+		return nil
+	})
+}
+
+// AssertScreenUnchanged asserts that running action does not change the screen.
+// The screen is captured before action runs and compared again after waiting settle.
+// This assertion does not retry, since a later change is exactly what it detects.
+func (e *Emulator) AssertScreenUnchanged(t TestingT, action func() error, settle time.Duration) {
+	t.Helper()
+
+	before, err := e.GetScreenText()
+	if err != nil {
+		t.Fatalf("failed to get screen: %v", err)
+		return
+	}
+
+	if err := action(); err != nil {
+		t.Fatalf("action failed: %v", err)
+		return
+	}
+	time.Sleep(settle)
+
+	after, err := e.GetScreenText()
+	if err != nil {
+		t.Fatalf("failed to get screen: %v", err)
+		return
+	}
+
+	if after != before {
+		t.Fatalf("screen changed after action:\n--- before ---\n%s\n--- after ---\n%s", before, after)
+	}
+}
+
+// AssertScreenTemplate asserts that the screen matches template cell by cell,
+// where '.' matches any cell. Use it to pin a layout (frames, separators) while
+// ignoring variable content. A single leading and trailing newline in template is
+// ignored; rows below the template are not checked.
+func (e *Emulator) AssertScreenTemplate(t TestingT, template string) {
+	t.Helper()
+
+	template = strings.TrimPrefix(template, "\n")
+	template = strings.TrimSuffix(template, "\n")
+
+	e.assertWithRetry(t, func() error {
+		got, err := e.getScreenText()
+		if err != nil {
+			return fmt.Errorf("failed to get screen: %v", err)
+		}
+
+		if msg := templateMismatch(template, got); msg != "" {
+			return fmt.Errorf("%s\n--- screen ---\n%s", msg, got)
+		}
+		return nil
+	})
+}
+
+// AssertCursorAfter asserts that the cursor is positioned immediately after substr
+// on the cursor's current row. Columns are measured in display cells, so wide
+// characters before the cursor are accounted for.
+func (e *Emulator) AssertCursorAfter(t TestingT, substr string) {
+	t.Helper()
+
+	substr = e.normalize(substr)
+
+	e.assertWithRetry(t, func() error {
+		row, col, err := e.GetCursorPosition()
+		if err != nil {
+			return fmt.Errorf("failed to get cursor position: %v", err)
+		}
+
+		line, err := e.GetLine(row - 1)
+		if err != nil {
+			return fmt.Errorf("failed to get line %d: %v", row-1, err)
+		}
+		line = e.normalize(line)
+
+		if !strings.Contains(line, substr) {
+			return fmt.Errorf("cursor row %d does not contain %q:\n%q", row-1, substr, line)
+		}
+
+		// Any occurrence ending at the cursor column satisfies the assertion
+		for offset := 0; ; {
+			idx := strings.Index(line[offset:], substr)
+			if idx < 0 {
+				break
+			}
+			end := offset + idx + len(substr)
+			if runewidth.StringWidth(line[:end])+1 == col {
+				return nil
+			}
+			offset += idx + 1
+		}
+		return fmt.Errorf("cursor is not after %q on row %d:\nline:   %q\ncursor: col %d", substr, row-1, line, col)
+	})
+}
+
+// TypeAndExpect sends text and asserts that it is echoed on the cursor line,
+// ending right before the cursor, so dropped or reordered characters are caught
+// immediately. It relies on the echo of a cooked-mode terminal or a line editor;
+// if the program turns echo off (raw mode without echo, password prompts) nothing
+// is shown and the assertion fails, so send such input with KeyPress instead.
+func (e *Emulator) TypeAndExpect(t TestingT, text string) {
+	t.Helper()
+
+	if err := e.KeyPress([]byte(text)); err != nil {
+		t.Fatalf("failed to send %q: %v", text, err)
+		return
+	}
+	e.AssertCursorAfter(t, text)
+}
+
+// AssertCursorAt asserts that the cursor is at row and col, 1-based as returned by
+// GetCursorPosition. It retries with exponential backoff, so the screen may still
+// be settling after a keypress.
+func (e *Emulator) AssertCursorAt(t TestingT, row, col int) {
+	t.Helper()
+
+	e.assertWithRetry(t, func() error {
+		gotRow, gotCol, err := e.GetCursorPosition()
+		if err != nil {
+			return fmt.Errorf("failed to get cursor position: %v", err)
+		}
+
+		if gotRow != row || gotCol != col {
+			return fmt.Errorf("cursor position mismatch:\nwant: row %d, col %d\ngot:  row %d, col %d", row, col, gotRow, gotCol)
+		}
+		return nil
+	})
+}
+
+// AssertCursorInRegion asserts that the cursor lies within the rectangle from
+// (top, left) to (bottom, right), inclusive. Positions are 1-based, as returned by
+// GetCursorPosition. Use it to check that focus is inside a panel or dialog.
+func (e *Emulator) AssertCursorInRegion(t TestingT, top, left, bottom, right int) {
+	t.Helper()
+
+	e.assertWithRetry(t, func() error {
+		row, col, err := e.GetCursorPosition()
+		if err != nil {
+			return fmt.Errorf("failed to get cursor position: %v", err)
+		}
+
+		if row < top || row > bottom || col < left || col > right {
+			return fmt.Errorf("cursor at row %d, col %d is outside region rows %d-%d, cols %d-%d", row, col, top, bottom, left, right)
+		}
+		return nil
+	})
+}
+
+// AssertCharAt asserts that the cell at row and col (0-based) holds want; ' '
+// matches a blank cell. For a character libvterm stores as wide, the following
+// cell must be its empty continuation cell. Use it to check column placement in
+// CJK-heavy layouts.
+func (e *Emulator) AssertCharAt(t TestingT, row, col int, want rune) {
+	t.Helper()
+
+	e.assertWithRetry(t, func() error {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		if e.screen == nil {
+			return fmt.Errorf("emulator not started")
+		}
+		if row < 0 || row >= int(e.rows) || col < 0 || col >= int(e.cols) {
+			return fmt.Errorf("cell (%d, %d) out of range for %dx%d screen", row, col, e.rows, e.cols)
+		}
+
+		got, width, err := e.cellAt(row, col)
+		if err != nil {
+			return fmt.Errorf("failed to get cell at row %d, col %d: %v", row, col, err)
+		}
+		if got != want {
+			return fmt.Errorf("cell at row %d, col %d:\nwant: %q\ngot:  %q (width %d)", row, col, want, got, width)
+		}
+
+		if width == 2 && col+1 < int(e.cols) {
+			next, _, err := e.cellAt(row, col+1)
+			if err != nil {
+				return fmt.Errorf("failed to get cell at row %d, col %d: %v", row, col+1, err)
+			}
+			if next != 0 {
+				return fmt.Errorf("continuation cell of %q at row %d, col %d holds %q", want, row, col+1, next)
+			}
+		}
+		return nil
+	})
+}
+
+// AssertAppearsWithin asserts that text appears on the screen within d of the call.
+// Unlike the other assertions it uses a fixed deadline instead of retry settings.
+// On failure it keeps watching for another d to tell a slow program
+// ("appeared after 180ms") from one that never shows the text.
+func (e *Emulator) AssertAppearsWithin(t TestingT, text string, d time.Duration) {
+	t.Helper()
+
+	text = e.normalize(text)
+	start := time.Now()
+	deadline := start.Add(d)
+	grace := deadline.Add(d)
+
+	for {
+		got, err := e.GetScreenText()
+		if err != nil {
+			t.Fatalf("failed to get screen: %v", err)
+			return
+		}
+		got = e.normalize(got)
+
+		if strings.Contains(got, text) {
+			if elapsed := time.Since(start); elapsed > d {
+				t.Fatalf("%q took too long to appear: appeared after %v, limit %v", text, elapsed.Round(time.Millisecond), d)
+			}
+			return
+		}
+		if time.Now().After(grace) {
+			t.Fatalf("%q never appeared within %v (limit %v):\n%s", text, 2*d, d, got)
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// QuitAndExpect sends quitKeys and asserts that want appears on the screen, e.g. the
+// shell prompt or a goodbye message shown after ":wq". It succeeds as soon as want
+// appears. If the program exits first, the screen is checked once more after all
+// of its output has been read, so the final frame is not lost to the exit.
+// Fails if want is not on the screen by timeout or after the program exited.
+func (e *Emulator) QuitAndExpect(t TestingT, quitKeys [][]byte, want string, timeout time.Duration) {
+	t.Helper()
+
+	if err := e.KeyPress(quitKeys...); err != nil {
+		t.Fatalf("failed to send quit keys: %v", err)
+		return
+	}
+
+	want = e.normalize(want)
+	deadline := time.After(timeout)
+	for {
+		exited := false
+		select {
+		case <-e.readerDone:
+			exited = true
+		default:
+		}
+
+		got, err := e.GetScreenText()
+		if err != nil {
+			t.Fatalf("failed to get screen: %v", err)
+			return
+		}
+		got = e.normalize(got)
+
+		if strings.Contains(got, want) {
+			return
+		}
+		if exited {
+			t.Fatalf("%q not found after program exited:\n%s", want, got)
+			return
+		}
+
+		select {
+		case <-deadline:
+			t.Fatalf("%q did not appear within %v and program did not exit:\n%s", want, timeout, got)
+			return
+		case <-e.readerDone:
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
 // assertWithRetry implements the retry logic with exponential backoff
 func (e *Emulator) assertWithRetry(t TestingT, check func() error) {
 	t.Helper()
@@ -87,9 +548,18 @@ func (e *Emulator) assertWithRetry(t TestingT, check func() error) {
 	backoffFactor := e.getBackoffFactor()
 	
 	var lastErr error
+
+	name := ""
+	if e.assertCfg.logf != nil {
+		name = assertionName()
+		e.logAssert("%s: checking", name)
+	}
 	
 	for attempt := 0; attempt < maxAttempts; attempt++ {
 		if err := check(); err == nil {
+			if name != "" {
+				e.logAssert("%s: passed (attempt %d/%d)", name, attempt+1, maxAttempts)
+			}
 			return // Success
 		} else {
 			lastErr = err
@@ -104,6 +574,9 @@ func (e *Emulator) assertWithRetry(t TestingT, check func() error) {
 	
 	// All attempts failed
 	if lastErr != nil {
+		if name != "" {
+			e.logAssert("%s: failed after %d attempts", name, maxAttempts)
+		}
 		t.Fatalf("assertion failed after %d attempts: %v", maxAttempts, lastErr)
 	}
 }
@@ -113,6 +586,11 @@ type assertConfig struct {
 	maxAttempts    int
 	initialDelay   time.Duration
 	backoffFactor  float64
+
+	normalize bool
+	normForm  norm.Form
+
+	logf func(format string, args ...interface{})
 }
 
 // Add to Emulator struct (in emulator.go):
@@ -155,4 +633,21 @@ func (e *Emulator) WithAssertInitialDelay(d time.Duration) *Emulator {
 func (e *Emulator) WithAssertBackoffFactor(f float64) *Emulator {
 	e.assertCfg.backoffFactor = f
 	return e
-}
\ No newline at end of file
+}
+
+// WithAssertUnicodeNormalization applies the given Unicode normalization form
+// (e.g. norm.NFC or norm.NFD) to both expected and actual text in assertions.
+// Normalization is disabled by default.
+func (e *Emulator) WithAssertUnicodeNormalization(form norm.Form) *Emulator {
+	e.assertCfg.normalize = true
+	e.assertCfg.normForm = form
+	return e
+}
+
+// normalize applies the configured Unicode normalization, if any
+func (e *Emulator) normalize(s string) string {
+	if !e.assertCfg.normalize {
+		return s
+	}
+	return e.assertCfg.normForm.String(s)
+}
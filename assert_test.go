@@ -2,12 +2,16 @@ package vtermtest_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/c-bata/vtermtest"
+	"github.com/c-bata/vtermtest/keys"
+	"golang.org/x/text/unicode/norm"
 )
 
 func TestAssertions(t *testing.T) {
@@ -45,6 +49,12 @@ func TestAssertions(t *testing.T) {
 Hello
 World
 `)
+
+		mt := &mockTest{}
+		emu.WithAssertMaxAttempts(1).AssertScreenEqual(mt, "Hello\nThere")
+		if !mt.failed || !strings.Contains(mt.message, "--- want\n+++ got\n Hello\n-There\n+World\n") {
+			t.Errorf("expected a row diff, got: %q", mt.message)
+		}
 	})
 
 	t.Run("AssertScreenContains", func(t *testing.T) {
@@ -62,6 +72,26 @@ World
 	})
 }
 
+func TestCarriageReturnProgress(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(5, 40).
+		Command("sh", "-c", "printf '  0%%\r 50%%\r100%%\n'; printf 'loading...\rdone\033[K\n'; printf 'loading...\rdone\n'").
+		Env("LANG=C.UTF-8")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	// Same-width updates fully overwrite the previous value
+	emu.AssertLineEqual(t, 0, "100%")
+	// Shorter text followed by erase-to-end-of-line leaves nothing behind
+	emu.AssertLineEqual(t, 1, "done")
+	// Without erasing, the uncovered cells keep their old content like a real terminal
+	emu.AssertLineEqual(t, 2, "doneing...")
+}
+
 func TestAssertRetry(t *testing.T) {
 	ctx := context.Background()
 
@@ -108,7 +138,7 @@ func TestAssertFailure(t *testing.T) {
 	t.Run("LineEqual fails on mismatch", func(t *testing.T) {
 		// Use a custom test type to capture failures
 		mockT := &mockTest{}
-		
+
 		emu := vtermtest.New(5, 40).
 			Command("echo", "actual").
 			Env("LANG=C.UTF-8").
@@ -133,6 +163,114 @@ func TestAssertFailure(t *testing.T) {
 	})
 }
 
+func TestAssertFunc(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(5, 40).
+		Command("echo", "count: 42").
+		Env("LANG=C.UTF-8")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	emu.AssertScreenFunc(t, func(screen string) error {
+		if !strings.Contains(screen, "count:") {
+			return errors.New("count not shown")
+		}
+		return nil
+	})
+
+	emu.AssertLineFunc(t, 0, func(line string) error {
+		n, err := strconv.Atoi(strings.TrimPrefix(line, "count: "))
+		if err != nil {
+			return err
+		}
+		if n < 40 || n > 50 {
+			return fmt.Errorf("count %d out of range", n)
+		}
+		return nil
+	})
+
+	mockT := &mockTest{}
+	emu.WithAssertMaxAttempts(1).AssertLineFunc(mockT, 0, func(line string) error {
+		return errors.New("custom failure")
+	})
+	if !mockT.failed {
+		t.Error("AssertLineFunc should have failed")
+	}
+	if !strings.Contains(mockT.message, "custom failure") {
+		t.Errorf("Error message should contain the check error, got: %s", mockT.message)
+	}
+}
+
+func TestAssertUnicodeNormalization(t *testing.T) {
+	ctx := context.Background()
+
+	// The program prints the composed form (U+00E9)
+	emu := vtermtest.New(5, 40).
+		Command("printf", "caf\u00e9").
+		Env("LANG=C.UTF-8").
+		WithAssertUnicodeNormalization(norm.NFC)
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	// The expectation uses the decomposed form (e + U+0301)
+	emu.AssertLineEqual(t, 0, "cafe\u0301")
+	emu.AssertScreenContains(t, "cafe\u0301")
+}
+
+func TestAssertScreenUnchanged(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(5, 40).
+		Command("sh", "-c", "stty -echo; echo ready; read x; echo changed; sleep 1").
+		Env("LANG=C.UTF-8")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	emu.AssertScreenContains(t, "ready")
+
+	// Typing without echo must not change the screen
+	emu.AssertScreenUnchanged(t, func() error {
+		return emu.KeyPress([]byte("abc"))
+	}, 100*time.Millisecond)
+
+	mockT := &mockTest{}
+	emu.AssertScreenUnchanged(mockT, func() error {
+		return emu.KeyPress([]byte("\r"))
+	}, 200*time.Millisecond)
+	if !mockT.failed {
+		t.Error("AssertScreenUnchanged should have failed")
+	}
+}
+
+func TestAssertScreenTemplate(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(5, 40).
+		Command("sh", "-c", "echo '+--------+'; echo '| 37 ms  |'; echo '+--------+'").
+		Env("LANG=C.UTF-8")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	emu.AssertScreenTemplate(t, `
++--------+
+|........|
++--------+
+`)
+}
+
 // mockTest implements a minimal testing.T interface for testing failures
 type mockTest struct {
 	failed  bool
@@ -144,4 +282,272 @@ func (m *mockTest) Helper() {}
 func (m *mockTest) Fatalf(format string, args ...interface{}) {
 	m.failed = true
 	m.message = fmt.Sprintf(format, args...)
-}
\ No newline at end of file
+}
+
+func TestAssertCursorAfter(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(5, 40).
+		Command("sh", "-c", "stty raw -echo; printf '>>> '; cat").
+		Env("LANG=C.UTF-8")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	emu.AssertScreenContains(t, ">>>")
+
+	if err := emu.KeyPress([]byte("hello")); err != nil {
+		t.Fatalf("failed to send keys: %v", err)
+	}
+
+	emu.AssertCursorAfter(t, ">>> hello")
+	emu.AssertCursorAfter(t, "hello")
+}
+
+func TestAssertAppearsWithin(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(5, 40).
+		Command("sh", "-c", "echo 'fast'; sleep 0.3; echo 'slow'; sleep 5").
+		Env("LANG=C.UTF-8")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	emu.AssertAppearsWithin(t, "fast", 2*time.Second)
+
+	mt := &mockTest{}
+	emu.AssertAppearsWithin(mt, "slow", 200*time.Millisecond)
+	if !mt.failed || !strings.Contains(mt.message, "took too long") {
+		t.Errorf("expected a took too long failure, got: %q", mt.message)
+	}
+
+	mt = &mockTest{}
+	emu.AssertAppearsWithin(mt, "missing", 50*time.Millisecond)
+	if !mt.failed || !strings.Contains(mt.message, "never appeared") {
+		t.Errorf("expected a never appeared failure, got: %q", mt.message)
+	}
+}
+
+func TestAssertCursorInRegion(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(10, 40).
+		Command("sh", "-c", "printf '\\033[4;11Hname: '; sleep 5").
+		Env("LANG=C.UTF-8").
+		WithAssertMaxAttempts(2)
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	emu.AssertScreenContains(t, "name:")
+
+	// Cursor is at row 4, col 17 after the label
+	emu.AssertCursorInRegion(t, 3, 10, 6, 30)
+	emu.AssertCursorInRegion(t, 4, 17, 4, 17)
+
+	mt := &mockTest{}
+	emu.AssertCursorInRegion(mt, 5, 1, 8, 40)
+	if !mt.failed || !strings.Contains(mt.message, "cursor at row 4, col 17 is outside region") {
+		t.Errorf("expected failure with the actual position, got: %q", mt.message)
+	}
+}
+
+func TestTypeAndExpect(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(5, 40).
+		Command("sh", "-c", "printf 'name: '; read name; echo \"hi $name\"; stty -echo; printf 'password: '; read pw; sleep 5").
+		Env("LANG=C.UTF-8").
+		WithAssertMaxAttempts(3)
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	emu.AssertScreenContains(t, "name:")
+	emu.TypeAndExpect(t, "alice")
+	if err := emu.KeyPress(keys.Enter); err != nil {
+		t.Fatal(err)
+	}
+	emu.AssertScreenContains(t, "password:")
+
+	// Without echo the typed text never appears
+	mt := &mockTest{}
+	emu.TypeAndExpect(mt, "secret")
+	if !mt.failed {
+		t.Error("expected TypeAndExpect to fail without echo")
+	}
+}
+
+func TestAssertScreenOneOf(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(3, 40).
+		Command("sh", "-c", "echo \"file 'a.txt' not found\"; sleep 5").
+		Env("LANG=C.UTF-8").
+		WithAssertMaxAttempts(2)
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	emu.AssertScreenOneOf(t, `file "a.txt" not found`, `file 'a.txt' not found`)
+
+	mt := &mockTest{}
+	emu.AssertScreenOneOf(mt, `file "a.txt" not found`, `file a.txt not found`)
+	if !mt.failed {
+		t.Fatal("expected AssertScreenOneOf to fail")
+	}
+	for _, want := range []string{"none of 2 variants", "variant 0: 2 cells differ", "closest is variant 0"} {
+		if !strings.Contains(mt.message, want) {
+			t.Errorf("expected failure message to contain %q, got:\n%s", want, mt.message)
+		}
+	}
+}
+
+func TestAssertCharAt(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(3, 20).
+		Command("sh", "-c", "printf 'a日本b'; sleep 5").
+		Env("LANG=C.UTF-8").
+		WithAssertMaxAttempts(2)
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	emu.AssertLineEqual(t, 0, "a日本b")
+	emu.AssertCharAt(t, 0, 0, 'a')
+	emu.AssertCharAt(t, 0, 1, '日')
+	emu.AssertCharAt(t, 0, 3, '本')
+	emu.AssertCharAt(t, 0, 5, 'b')
+	emu.AssertCharAt(t, 0, 6, ' ')
+
+	mt := &mockTest{}
+	emu.AssertCharAt(mt, 0, 2, '本')
+	if !mt.failed || !strings.Contains(mt.message, "got:  '\\x00' (width 1)") {
+		t.Errorf("expected the continuation cell to be reported, got: %q", mt.message)
+	}
+}
+
+func TestQuitAndExpect(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(5, 40).
+		Command("sh", "-c", "read cmd; [ \"$cmd\" = q ] && printf 'bye'").
+		Env("LANG=C.UTF-8")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	emu.QuitAndExpect(t, [][]byte{[]byte("q"), keys.Enter}, "bye", 5*time.Second)
+
+	mt := &mockTest{}
+	emu.QuitAndExpect(mt, nil, "see you", 5*time.Second)
+	if !mt.failed || !strings.Contains(mt.message, "after program exited") {
+		t.Errorf("expected an after program exited failure, got: %q", mt.message)
+	}
+}
+
+func TestWithAssertVerbose(t *testing.T) {
+	ctx := context.Background()
+
+	var logs []string
+	emu := vtermtest.New(5, 40).
+		Command("sh", "-c", "printf '\\033[?2004h'; echo 'ready'; sleep 5").
+		Env("LANG=C.UTF-8").
+		WithAssertMaxAttempts(2).
+		WithAssertVerbose(func(format string, args ...interface{}) {
+			logs = append(logs, fmt.Sprintf(format, args...))
+		})
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	emu.AssertScreenContains(t, "ready")
+	emu.AssertModeEnabled(t, vtermtest.ModeBracketedPaste)
+	emu.AssertScreenContains(&mockTest{}, "missing")
+
+	want := []string{
+		"AssertScreenContains: checking",
+		"AssertScreenContains: passed (attempt 1/2)",
+		"AssertModeEnabled: checking",
+		"AssertModeEnabled: passed (attempt 1/2)",
+		"AssertScreenContains: checking",
+		"AssertScreenContains: failed after 2 attempts",
+	}
+	if strings.Join(logs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected log:\n%s\nwant:\n%s", strings.Join(logs, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestAssertScreenNotContains(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(5, 40).
+		Command("sh", "-c", "stty -echo; printf 'Password: '; read pw; echo; echo 'logged in'; sleep 5").
+		Env("LANG=C.UTF-8").
+		WithAssertMaxAttempts(3).
+		WithAssertInitialDelay(10 * time.Millisecond)
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	emu.AssertScreenContains(t, "Password:")
+	if err := emu.KeyPress(keys.Text("hunter2"), keys.Enter); err != nil {
+		t.Fatalf("KeyPress failed: %v", err)
+	}
+	emu.AssertScreenContains(t, "logged in")
+	emu.AssertScreenNotContains(t, "hunter2")
+
+	mt := &mockTest{}
+	emu.AssertScreenNotContains(mt, "logged in")
+	if !mt.failed || !strings.Contains(mt.message, `screen contains "logged in" (attempt 1/3)`) {
+		t.Errorf("expected a fail-fast failure, got: %q", mt.message)
+	}
+}
+
+func TestAssertScreenMatches(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(5, 40).
+		Command("sh", "-c", "echo 'mysql> SELECT 1;'; echo \"$$ rows in set\"; sleep 5").
+		Env("LANG=C.UTF-8").
+		WithAssertMaxAttempts(2)
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	emu.AssertScreenMatches(t, `^\d+ rows in set$`)
+
+	mt := &mockTest{}
+	emu.AssertScreenMatches(mt, `^SELECT`)
+	if !mt.failed || !strings.Contains(mt.message, `screen does not match "^SELECT"`) {
+		t.Errorf("expected a pattern mismatch, got: %q", mt.message)
+	}
+
+	mt = &mockTest{}
+	emu.AssertScreenMatches(mt, `(`)
+	if !mt.failed || !strings.Contains(mt.message, "invalid pattern") {
+		t.Errorf("expected an invalid pattern failure, got: %q", mt.message)
+	}
+}
@@ -0,0 +1,108 @@
+package vtermtest
+
+import (
+	"fmt"
+	"strings"
+
+	libvterm "github.com/mattn/go-libvterm"
+)
+
+// Attribute is a set of cell text attributes. Combine them with |.
+type Attribute int
+
+// Cell text attributes.
+const (
+	AttrBold Attribute = 1 << iota
+	AttrUnderline
+	AttrItalic
+	AttrBlink
+	AttrReverse
+	AttrStrike
+)
+
+var attributeNames = []struct {
+	attr Attribute
+	name string
+}{
+	{AttrBold, "bold"},
+	{AttrUnderline, "underline"},
+	{AttrItalic, "italic"},
+	{AttrBlink, "blink"},
+	{AttrReverse, "reverse"},
+	{AttrStrike, "strike"},
+}
+
+// String returns the attribute names joined with "|", or "none".
+func (a Attribute) String() string {
+	var names []string
+	for _, n := range attributeNames {
+		if a&n.attr != 0 {
+			names = append(names, n.name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, "|")
+}
+
+// cellAttributes converts libvterm cell attributes to an Attribute set.
+func cellAttributes(cell *libvterm.ScreenCell) Attribute {
+	attrs := cell.Attrs()
+	var a Attribute
+	if attrs.Bold != 0 {
+		a |= AttrBold
+	}
+	if attrs.Underline != 0 {
+		a |= AttrUnderline
+	}
+	if attrs.Italic != 0 {
+		a |= AttrItalic
+	}
+	if attrs.Blink != 0 {
+		a |= AttrBlink
+	}
+	if attrs.Reverse != 0 {
+		a |= AttrReverse
+	}
+	if attrs.Strike != 0 {
+		a |= AttrStrike
+	}
+	return a
+}
+
+// AssertRegionStyled asserts that every non-blank cell in the rectangle from
+// (top, left) to (bottom, right), inclusive, has all attributes in requireAttr,
+// e.g. AttrBold to check that a header row is bold. Rows and columns are 0-based
+// screen cells, as in GetLine. Blank cells are skipped, and colors are ignored.
+// The first non-conforming cell is reported on failure.
+func (e *Emulator) AssertRegionStyled(t TestingT, top, left, bottom, right int, requireAttr Attribute) {
+	t.Helper()
+
+	e.assertWithRetry(t, func() error {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		if e.screen == nil {
+			return fmt.Errorf("emulator not started")
+		}
+
+		for row := top; row <= bottom; row++ {
+			for col := left; col <= right; col++ {
+				cell, err := e.screen.GetCell(libvterm.NewPos(row, col))
+				if err != nil {
+					return fmt.Errorf("failed to get cell at row %d, col %d: %v", row, col, err)
+				}
+				chars := cell.Chars()
+				if len(chars) == 0 || chars[0] <= 0 || chars[0] == ' ' {
+					continue
+				}
+
+				if got := cellAttributes(cell); got&requireAttr != requireAttr {
+					return fmt.Errorf("cell %q at row %d, col %d has attributes %s, want %s", chars[0], row, col, got, requireAttr)
+				}
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,35 @@
+package vtermtest_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/c-bata/vtermtest"
+)
+
+func TestAssertRegionStyled(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(4, 40).
+		Command("sh", "-c", "printf '\\033[1;4mNAME  AGE\\033[0m\\n\\033[1mbob\\033[0m   42\\n'; sleep 5").
+		Env("LANG=C.UTF-8", "TERM=xterm").
+		WithAssertMaxAttempts(2)
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	emu.AssertLineEqual(t, 1, "bob   42")
+
+	// The header row is bold and underlined; the space between columns is skipped
+	emu.AssertRegionStyled(t, 0, 0, 0, 39, vtermtest.AttrBold|vtermtest.AttrUnderline)
+	emu.AssertRegionStyled(t, 1, 0, 1, 2, vtermtest.AttrBold)
+
+	mt := &mockTest{}
+	emu.AssertRegionStyled(mt, 0, 0, 1, 39, vtermtest.AttrBold)
+	if !mt.failed || !strings.Contains(mt.message, "cell '4' at row 1, col 6 has attributes none, want bold") {
+		t.Errorf("expected the first non-bold cell to be reported, got: %q", mt.message)
+	}
+}
@@ -0,0 +1,61 @@
+package vtermtest
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrorReporter is the subset of testing.T used to report batched failures
+type ErrorReporter interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// BatchAssert collects failures from multiple assertions instead of stopping at
+// the first one. Pass it in place of t to any Assert* method, then call Done to
+// report every failure together with t.Errorf.
+//
+//	b := vtermtest.NewBatchAssert(t)
+//	emu.AssertLineEqual(b, 0, "header")
+//	emu.AssertLineEqual(b, 1, "body")
+//	b.Done()
+type BatchAssert struct {
+	t        ErrorReporter
+	failures []string
+}
+
+// NewBatchAssert creates a BatchAssert reporting to t.
+func NewBatchAssert(t ErrorReporter) *BatchAssert {
+	return &BatchAssert{t: t}
+}
+
+// Helper implements TestingT by forwarding to the reporter's Helper.
+func (b *BatchAssert) Helper() {
+	b.t.Helper()
+}
+
+// Fatalf implements TestingT by recording the failure and letting the test continue.
+func (b *BatchAssert) Fatalf(format string, args ...interface{}) {
+	b.failures = append(b.failures, fmt.Sprintf(format, args...))
+}
+
+// Failures returns the failures recorded so far.
+func (b *BatchAssert) Failures() []string {
+	return b.failures
+}
+
+// Done reports all recorded failures with a single t.Errorf call, if any.
+func (b *BatchAssert) Done() {
+	b.t.Helper()
+
+	if len(b.failures) == 0 {
+		return
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "%d assertion(s) failed:", len(b.failures))
+	for i, failure := range b.failures {
+		fmt.Fprintf(&msg, "\n[%d] %s", i+1, failure)
+	}
+	b.t.Errorf("%s", msg.String())
+}
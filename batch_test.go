@@ -0,0 +1,82 @@
+package vtermtest_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/c-bata/vtermtest"
+)
+
+func TestBatchAssert(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(5, 40).
+		Command("echo", "line1\nline2").
+		Env("LANG=C.UTF-8").
+		WithAssertMaxAttempts(2)
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	emu.AssertScreenContains(t, "line2")
+
+	reporter := &mockReporter{}
+	b := vtermtest.NewBatchAssert(reporter)
+	emu.AssertLineEqual(b, 0, "wrong1")
+	emu.AssertLineEqual(b, 1, "line2")
+	emu.AssertLineEqual(b, 1, "wrong2")
+	if reporter.helpers == 0 {
+		t.Error("expected Helper calls to be forwarded to the reporter")
+	}
+	b.Done()
+
+	if len(b.Failures()) != 2 {
+		t.Fatalf("expected 2 failures, got %d: %v", len(b.Failures()), b.Failures())
+	}
+	if len(reporter.errors) != 1 {
+		t.Fatalf("expected a single Errorf call, got %d", len(reporter.errors))
+	}
+	for _, want := range []string{"2 assertion(s) failed", "wrong1", "wrong2"} {
+		if !strings.Contains(reporter.errors[0], want) {
+			t.Errorf("report should contain %q, got: %s", want, reporter.errors[0])
+		}
+	}
+}
+
+func TestBatchAssertCursorMismatch(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(5, 40).
+		Command("sh", "-c", "printf 'ab'; sleep 5").
+		Env("LANG=C.UTF-8")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	emu.AssertCursorAt(t, 1, 3)
+
+	b := vtermtest.NewBatchAssert(&mockReporter{})
+	emu.WithAssertMaxAttempts(1).AssertCursorAt(b, 2, 1)
+	want := "cursor position mismatch:\nwant: row 2, col 1\ngot:  row 1, col 3"
+	if failures := b.Failures(); len(failures) != 1 || !strings.HasSuffix(failures[0], want) {
+		t.Errorf("unexpected failures: %q", failures)
+	}
+}
+
+// mockReporter implements vtermtest.ErrorReporter for testing batched failures
+type mockReporter struct {
+	errors  []string
+	helpers int
+}
+
+func (m *mockReporter) Helper() { m.helpers++ }
+
+func (m *mockReporter) Errorf(format string, args ...interface{}) {
+	m.errors = append(m.errors, fmt.Sprintf(format, args...))
+}
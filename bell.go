@@ -0,0 +1,32 @@
+package vtermtest
+
+import "fmt"
+
+// onBell counts a BEL from the program. It runs while libvterm processes output,
+// with e.mu held.
+func (e *Emulator) onBell() int {
+	e.bellCount++
+	return 1
+}
+
+// BellCount returns how many times the program rang the bell (BEL, 0x07) since
+// Start or the last Reset. A BEL terminating an OSC sequence does not count.
+func (e *Emulator) BellCount() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.bellCount
+}
+
+// AssertBellCount asserts that the program rang the bell exactly n times, e.g.
+// once after an invalid key. It retries with exponential backoff.
+func (e *Emulator) AssertBellCount(t TestingT, n int) {
+	t.Helper()
+
+	e.assertWithRetry(t, func() error {
+		if got := e.BellCount(); got != n {
+			return fmt.Errorf("bell count mismatch: want %d, got %d", n, got)
+		}
+		return nil
+	})
+}
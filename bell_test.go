@@ -0,0 +1,45 @@
+package vtermtest_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/c-bata/vtermtest"
+)
+
+func TestBellCount(t *testing.T) {
+	ctx := context.Background()
+
+	// The title's BEL terminator is not a bell
+	emu := vtermtest.New(4, 40).
+		Command("sh", "-c", "printf '\\033]0;title\\007ready'; while read x; do printf '\\007'; done").
+		Env("LANG=C.UTF-8").
+		WithAssertMaxAttempts(3)
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	emu.AssertScreenContains(t, "ready")
+	emu.AssertBellCount(t, 0)
+
+	if err := emu.KeyPressString("x<Enter>y<Enter>"); err != nil {
+		t.Fatalf("KeyPressString failed: %v", err)
+	}
+	emu.AssertBellCount(t, 2)
+
+	if err := emu.Reset(); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	if got := emu.BellCount(); got != 0 {
+		t.Errorf("BellCount() after Reset = %d, want 0", got)
+	}
+
+	mt := &mockTest{}
+	emu.AssertBellCount(mt, 1)
+	if !mt.failed || !strings.Contains(mt.message, "bell count mismatch: want 1, got 0") {
+		t.Errorf("expected a bell count mismatch, got: %q", mt.message)
+	}
+}
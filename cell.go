@@ -0,0 +1,191 @@
+package vtermtest
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"strings"
+
+	libvterm "github.com/mattn/go-libvterm"
+)
+
+// Cell describes a single screen cell.
+type Cell struct {
+	// Rune is the character in the cell, or 0 for an empty cell.
+	Rune rune
+	// Width is the number of columns the character occupies (2 for wide characters).
+	Width int
+	// FgRGB and BgRGB are the foreground and background colors.
+	FgRGB, BgRGB color.RGBA
+	// Text attributes of the cell; Attrs returns all of them as a set.
+	Bold, Underline, Italic, Reverse bool
+
+	attrs Attribute
+}
+
+// Attrs returns the cell's text attributes, including blink and strike.
+func (c Cell) Attrs() Attribute {
+	return c.attrs
+}
+
+// GetCell returns the cell at row and col (0-based). The second column of a wide
+// character is reported as an empty cell.
+func (e *Emulator) GetCell(row, col int) (Cell, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.getCell(row, col)
+}
+
+// GetCursorCell returns the cell under the cursor, e.g. to check that an editor
+// draws its block cursor as reverse video. When the cursor is past the end of the
+// line's text the empty cell there is returned, which still carries the attributes
+// the program drew it with. When the cursor is on the second column of a wide
+// character, the wide character's cell is returned.
+func (e *Emulator) GetCursorCell() (Cell, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.state == nil {
+		return Cell{}, e.stateErr()
+	}
+
+	row, col := e.state.GetCursorPos()
+	if col >= int(e.cols) {
+		col = int(e.cols) - 1
+	}
+
+	cell, err := e.getCell(row, col)
+	if err != nil {
+		return Cell{}, err
+	}
+	if cell.Rune == 0 && col > 0 {
+		if prev, err := e.getCell(row, col-1); err == nil && prev.Width == 2 {
+			return prev, nil
+		}
+	}
+	return cell, nil
+}
+
+// getCell reads a cell from libvterm. The caller must hold e.mu.
+func (e *Emulator) getCell(row, col int) (Cell, error) {
+	if e.screen == nil {
+		return Cell{}, errors.New("emulator not started")
+	}
+	if row < 0 || row >= int(e.rows) || col < 0 || col >= int(e.cols) {
+		return Cell{}, fmt.Errorf("cell (%d, %d) out of range for %dx%d screen", row, col, e.rows, e.cols)
+	}
+
+	sc, err := e.screen.GetCell(libvterm.NewPos(row, col))
+	if err != nil {
+		return Cell{}, err
+	}
+
+	attrs := cellAttributes(sc)
+	cell := Cell{
+		Width:     sc.Width(),
+		FgRGB:     color.RGBAModel.Convert(sc.Fg()).(color.RGBA),
+		BgRGB:     color.RGBAModel.Convert(sc.Bg()).(color.RGBA),
+		Bold:      attrs&AttrBold != 0,
+		Underline: attrs&AttrUnderline != 0,
+		Italic:    attrs&AttrItalic != 0,
+		Reverse:   attrs&AttrReverse != 0,
+		attrs:     attrs,
+	}
+	if chars := sc.Chars(); len(chars) > 0 && chars[0] > 0 {
+		cell.Rune = chars[0]
+	}
+	return cell, nil
+}
+
+// DumpCells returns one line per non-blank cell, in row-major order, describing
+// its character, colors and attributes:
+//
+//	(0,4) 'X' fg=#F0F0F0 bg=#000000 bold underline
+//
+// Spaces are included only when they carry attributes or a background other than
+// libvterm's default (e.g. a reverse-video or colored bar). The second column of
+// a wide character is not listed separately.
+// Intended for debugging styling failures and for golden files; it reads every
+// cell and is not meant for hot paths. Returns "" if the emulator is not started.
+func (e *Emulator) DumpCells() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.screen == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	for row := 0; row < int(e.rows); row++ {
+		for col := 0; col < int(e.cols); col++ {
+			cell, err := e.getCell(row, col)
+			if err != nil {
+				continue
+			}
+			if (cell.Rune == 0 || cell.Rune == ' ') && cell.attrs == 0 && cell.BgRGB == defaultBackground {
+				continue
+			}
+
+			ch := cell.Rune
+			if ch == 0 {
+				ch = ' '
+			}
+			fmt.Fprintf(&b, "(%d,%d) %q fg=%s bg=%s", row, col, ch, hexColor(cell.FgRGB), hexColor(cell.BgRGB))
+			if cell.attrs != 0 {
+				b.WriteString(" " + strings.ReplaceAll(cell.attrs.String(), "|", " "))
+			}
+			b.WriteByte('\n')
+			if cell.Width > 1 {
+				col += cell.Width - 1
+			}
+		}
+	}
+	return b.String()
+}
+
+// hexColor formats c as #RRGGBB.
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B)
+}
+
+// CellStyle is the expected styling of a cell for AssertCellStyle.
+// A zero Fg or Bg is not checked; colors read from the screen are always opaque.
+type CellStyle struct {
+	Attrs  Attribute
+	Fg, Bg color.RGBA
+}
+
+// String formats the style as e.g. "bold|reverse fg=#FF0000", omitting unchecked colors.
+func (s CellStyle) String() string {
+	str := s.Attrs.String()
+	if s.Fg != (color.RGBA{}) {
+		str += " fg=" + hexColor(s.Fg)
+	}
+	if s.Bg != (color.RGBA{}) {
+		str += " bg=" + hexColor(s.Bg)
+	}
+	return str
+}
+
+// AssertCellStyle asserts that the cell at row and col (0-based) has exactly the
+// attributes in want.Attrs and, when set, the colors want.Fg and want.Bg.
+// It retries with exponential backoff while the screen settles.
+func (e *Emulator) AssertCellStyle(t TestingT, row, col int, want CellStyle) {
+	t.Helper()
+
+	e.assertWithRetry(t, func() error {
+		cell, err := e.GetCell(row, col)
+		if err != nil {
+			return fmt.Errorf("failed to get cell: %v", err)
+		}
+
+		got := CellStyle{Attrs: cell.attrs, Fg: cell.FgRGB, Bg: cell.BgRGB}
+		if got.Attrs != want.Attrs ||
+			(want.Fg != color.RGBA{} && got.Fg != want.Fg) ||
+			(want.Bg != color.RGBA{} && got.Bg != want.Bg) {
+			return fmt.Errorf("style mismatch at row %d, col %d (%q):\nwant: %s\ngot:  %s", row, col, cell.Rune, want, got)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,205 @@
+package vtermtest_test
+
+import (
+	"context"
+	"image/color"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/c-bata/vtermtest"
+)
+
+func TestGetCursorCell(t *testing.T) {
+	ctx := context.Background()
+
+	// Block cursor drawn as reverse video over 'c', then moved onto it
+	emu := vtermtest.New(4, 20).
+		Command("sh", "-c", "printf 'ab\\033[7mc\\033[0md\\033[1;3H'; read x; printf '\\033[2;1H日本\\033[2;4H'; read x; printf '\\033[3;1Hend\\033[3;10H'; sleep 5").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	waitCursor := func(row, col int) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			r, c, err := emu.GetCursorPosition()
+			if err == nil && r == row && c == col {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("cursor did not reach row %d, col %d (at %d, %d)", row, col, r, c)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	waitCursor(1, 3)
+	cell, err := emu.GetCursorCell()
+	if err != nil {
+		t.Fatalf("GetCursorCell failed: %v", err)
+	}
+	if cell.Rune != 'c' || cell.Attrs() != vtermtest.AttrReverse {
+		t.Errorf("got %q with %v, want 'c' with reverse", cell.Rune, cell.Attrs())
+	}
+
+	// On the second column of a wide character the character itself is reported
+	if err := emu.KeyPress([]byte("\r")); err != nil {
+		t.Fatalf("KeyPress failed: %v", err)
+	}
+	waitCursor(2, 4)
+	cell, err = emu.GetCursorCell()
+	if err != nil {
+		t.Fatalf("GetCursorCell failed: %v", err)
+	}
+	if cell.Rune != '本' || cell.Width != 2 {
+		t.Errorf("got %q (width %d), want '本' (width 2)", cell.Rune, cell.Width)
+	}
+
+	// Past the end of the text the cell is empty
+	if err := emu.KeyPress([]byte("\r")); err != nil {
+		t.Fatalf("KeyPress failed: %v", err)
+	}
+	waitCursor(3, 10)
+	cell, err = emu.GetCursorCell()
+	if err != nil {
+		t.Fatalf("GetCursorCell failed: %v", err)
+	}
+	if cell.Rune != 0 || cell.Attrs() != 0 {
+		t.Errorf("got %q with %v, want an empty cell", cell.Rune, cell.Attrs())
+	}
+}
+
+func TestDumpCells(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(3, 10).
+		Command("sh", "-c", "printf 'a \\033[1;4;38;2;255;0;0mB\\033[0m\\n\\033[7m \\033[0m\\n\\033[48;2;0;0;255m \\033[0m'; sleep 5").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	emu.AssertLineEqual(t, 0, "a B")
+
+	// The default colors depend on libvterm, so only the layout of each line is fixed
+	lines := strings.Split(strings.TrimSuffix(emu.DumpCells(), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines, want 4:\n%s", len(lines), strings.Join(lines, "\n"))
+	}
+	if !strings.HasPrefix(lines[0], "(0,0) 'a' fg=#") || strings.Contains(lines[0], "bold") {
+		t.Errorf("unexpected line for 'a': %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "(0,2) 'B' fg=#FF0000 bg=#") || !strings.HasSuffix(lines[1], " bold underline") {
+		t.Errorf("unexpected line for 'B': %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[2], "(1,0) ' ' fg=#") || !strings.HasSuffix(lines[2], " reverse") {
+		t.Errorf("unexpected line for the reverse space: %q", lines[2])
+	}
+	if !strings.HasPrefix(lines[3], "(2,0) ' ' fg=#") || !strings.HasSuffix(lines[3], " bg=#0000FF") {
+		t.Errorf("unexpected line for the colored space: %q", lines[3])
+	}
+}
+
+func TestGetCellReverseRow(t *testing.T) {
+	ctx := context.Background()
+
+	// A menu with the selected row highlighted in reverse video
+	emu := vtermtest.New(4, 10).
+		Command("sh", "-c", "printf 'apple\\n\\033[7mbanana\\033[0m\\ncherry'; sleep 5").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	emu.AssertLineEqual(t, 2, "cherry")
+
+	for row, want := range []bool{false, true, false} {
+		cell, err := emu.GetCell(row, 0)
+		if err != nil {
+			t.Fatalf("GetCell(%d, 0) failed: %v", row, err)
+		}
+		if cell.Reverse != want {
+			t.Errorf("row %d: reverse = %v, want %v", row, cell.Reverse, want)
+		}
+	}
+
+	if _, err := emu.GetCell(4, 0); err == nil {
+		t.Error("expected an error for a row out of range")
+	}
+	if _, err := emu.GetCell(0, -1); err == nil {
+		t.Error("expected an error for a column out of range")
+	}
+}
+
+func TestAssertCellStyle(t *testing.T) {
+	ctx := context.Background()
+
+	// A completion menu whose selected entry is bold on a blue background
+	emu := vtermtest.New(4, 20).
+		Command("sh", "-c", "printf 'select\\n\\033[1;38;2;255;255;255;48;2;0;0;255mselected\\033[0m'; sleep 5").
+		Env("LANG=C.UTF-8", "TERM=xterm").
+		WithAssertMaxAttempts(2)
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	emu.AssertLineEqual(t, 1, "selected")
+
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+	emu.AssertCellStyle(t, 1, 0, vtermtest.CellStyle{Attrs: vtermtest.AttrBold, Fg: white, Bg: blue})
+	emu.AssertCellStyle(t, 1, 7, vtermtest.CellStyle{Attrs: vtermtest.AttrBold, Bg: blue})
+	emu.AssertCellStyle(t, 0, 0, vtermtest.CellStyle{})
+
+	mt := &mockTest{}
+	emu.AssertCellStyle(mt, 1, 0, vtermtest.CellStyle{Attrs: vtermtest.AttrBold | vtermtest.AttrReverse, Bg: blue})
+	if !mt.failed || !strings.Contains(mt.message, "want: bold|reverse bg=#0000FF\ngot:  bold fg=#FFFFFF bg=#0000FF") {
+		t.Errorf("expected a readable style mismatch, got: %q", mt.message)
+	}
+}
+
+func TestWideCharacterColumns(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(2, 12).
+		Command("sh", "-c", "printf '日本語ABC'; sleep 5").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	if err := emu.WaitFor("ABC", 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	emu.AssertLineEqual(t, 0, "日本語ABC")
+
+	want := []struct {
+		char  rune
+		width int
+	}{
+		{'日', 2}, {0, 0}, {'本', 2}, {0, 0}, {'語', 2}, {0, 0},
+		{'A', 1}, {'B', 1}, {'C', 1}, {0, 0},
+	}
+	for col, w := range want {
+		cell, err := emu.GetCell(0, col)
+		if err != nil {
+			t.Fatalf("GetCell(0, %d) failed: %v", col, err)
+		}
+		if cell.Rune != w.char || (w.char != 0 && cell.Width != w.width) {
+			t.Errorf("col %d: got %q (width %d), want %q (width %d)", col, cell.Rune, cell.Width, w.char, w.width)
+		}
+	}
+}
@@ -212,7 +212,12 @@ KEY DSL:
     Keys: <Tab> <Enter> <BS> <Del> <Esc> <Space> <Up> <Down> <Left> <Right>
     Ctrl: <C-a> ... <C-z>  Alt: <A-a> ... <A-z>  Fn: <F1> ... <F24>
     Nav: <Home> <End> <PageUp> <PageDown>
-    Wait: <WaitStable> <WaitFor text>
+    Focus: <FocusIn> <FocusOut>
+    Mouse: <Mouse 10,20 Left> (row,col 0-based; Left Middle Right ScrollUp ScrollDown)
+    Wait: <WaitStable> <WaitFor text> <Sleep 200ms>
+    Label: <Label login> (names the step in error messages)
+    Raw: <Raw>{"a": "<b>"}</Raw> (verbatim up to </Raw>)
+    Paste: <Paste>text<EndPaste> (verbatim, as a bracketed paste)
     Escape: << (literal <)
 
 EXAMPLES:
@@ -0,0 +1,30 @@
+package vtermtest
+
+// ConcealMode selects how concealed (SGR 8) cells appear in screen text.
+type ConcealMode int
+
+const (
+	// ConcealReveal shows concealed text like any other text. This is the default.
+	ConcealReveal ConcealMode = iota
+	// ConcealBlank shows concealed cells as spaces, as a terminal would render them.
+	ConcealBlank
+)
+
+// concealFont is the alternate font (SGR 10+n) that concealed text is switched to
+// when ConcealBlank is set, since libvterm's cell attributes do not expose conceal.
+const concealFont = 8
+
+// WithConcealHandling sets how concealed (SGR 8) cells, such as typed passwords,
+// appear in GetScreenText, GetLine and the assertions built on them.
+// ConcealReveal (the default) keeps their text, so a test can check what was
+// entered; ConcealBlank replaces them with spaces, so a test can check that it is
+// not visibly rendered.
+//
+// With ConcealBlank, conceal in the program's output is mapped to alternate font
+// 8, so text a program draws in that font is also treated as concealed.
+// Returns self for method chaining.
+func (e *Emulator) WithConcealHandling(mode ConcealMode) *Emulator {
+	e.conceal = mode
+	e.sgrRewriter().concealAsFont = mode == ConcealBlank
+	return e
+}
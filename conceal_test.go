@@ -0,0 +1,35 @@
+package vtermtest
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSGRRewriterConceal(t *testing.T) {
+	r := &sgrRewriter{concealAsFont: true}
+	got := string(r.feed([]byte("\x1b[1;8mpw\x1b[28m \x1b[38;5;8;48;2;8;8;8m\x1b[34m")))
+	want := "\x1b[1;18mpw\x1b[10m \x1b[38;5;8;48;2;8;8;8m\x1b[34m"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWithConcealHandling(t *testing.T) {
+	for _, tt := range []struct {
+		mode ConcealMode
+		want string
+	}{
+		{ConcealReveal, "password: hunter2 ok"},
+		{ConcealBlank, "password:         ok"},
+	} {
+		emu := New(4, 40).
+			Command("printf", "password: \\033[8mhunter2\\033[28m ok").
+			WithConcealHandling(tt.mode)
+		t.Cleanup(func() { _ = emu.Close() })
+
+		if err := emu.Start(context.Background()); err != nil {
+			t.Fatalf("start: %v", err)
+		}
+		emu.AssertLineEqual(t, 0, tt.want)
+	}
+}
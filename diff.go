@@ -0,0 +1,161 @@
+package vtermtest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// firstMismatch describes the first position where want and got differ,
+// with a caret under the differing cell. Rows and columns are 0-based and
+// columns are measured in display cells. Returns "" if the texts are equal.
+func firstMismatch(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+
+	n := len(wantLines)
+	if len(gotLines) > n {
+		n = len(gotLines)
+	}
+
+	for row := 0; row < n; row++ {
+		var w, g string
+		if row < len(wantLines) {
+			w = wantLines[row]
+		}
+		if row < len(gotLines) {
+			g = gotLines[row]
+		}
+		if w == g {
+			continue
+		}
+
+		wr, gr := []rune(w), []rune(g)
+		i := 0
+		for i < len(wr) && i < len(gr) && wr[i] == gr[i] {
+			i++
+		}
+		col := runewidth.StringWidth(string(wr[:i]))
+
+		return fmt.Sprintf("first difference at row %d, col %d:\nwant: %s\ngot:  %s\n      %s^",
+			row, col, w, g, strings.Repeat(" ", col))
+	}
+	return ""
+}
+
+// columns expands a line into one rune per display column. The second column of
+// a wide character holds 0.
+func columns(line string) []rune {
+	var cols []rune
+	for _, r := range line {
+		cols = append(cols, r)
+		if runewidth.RuneWidth(r) == 2 {
+			cols = append(cols, 0)
+		}
+	}
+	return cols
+}
+
+// templateMismatch compares screen against template cell by cell, where '.'
+// in the template matches any cell. Rows below the template are not checked.
+// Returns a description of the first mismatch, or "" if the screen matches.
+func templateMismatch(template, screen string) string {
+	templateLines := strings.Split(template, "\n")
+	screenLines := strings.Split(screen, "\n")
+
+	for row, tline := range templateLines {
+		var sline string
+		if row < len(screenLines) {
+			sline = screenLines[row]
+		}
+		tcols, scols := columns(tline), columns(sline)
+
+		for col, want := range tcols {
+			if want == '.' || want == 0 {
+				continue
+			}
+			got := ' '
+			if col < len(scols) {
+				got = scols[col]
+			}
+			if got != want {
+				return fmt.Sprintf("template mismatch at row %d, col %d:\nwant: %s\ngot:  %s\n      %s^",
+					row, col, tline, sline, strings.Repeat(" ", col))
+			}
+		}
+	}
+	return ""
+}
+
+// differingCells counts the display cells that differ between two screen texts.
+// Missing rows and columns compare as spaces, so trailing-space trimming does not
+// count as a difference.
+func differingCells(a, b string) int {
+	aLines := strings.Split(a, "\n")
+	bLines := strings.Split(b, "\n")
+
+	n := len(aLines)
+	if len(bLines) > n {
+		n = len(bLines)
+	}
+
+	count := 0
+	for row := 0; row < n; row++ {
+		var acols, bcols []rune
+		if row < len(aLines) {
+			acols = columns(aLines[row])
+		}
+		if row < len(bLines) {
+			bcols = columns(bLines[row])
+		}
+
+		width := len(acols)
+		if len(bcols) > width {
+			width = len(bcols)
+		}
+		for col := 0; col < width; col++ {
+			ac, bc := rune(' '), rune(' ')
+			if col < len(acols) {
+				ac = acols[col]
+			}
+			if col < len(bcols) {
+				bc = bcols[col]
+			}
+			if ac != bc {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// lineDiff compares want and got row by row, in the style of a unified diff:
+// matching rows are prefixed with a space, and a differing row is shown as the
+// wanted line prefixed with '-' followed by the actual line prefixed with '+'.
+// Missing rows are shown only on the side that has them.
+func lineDiff(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+
+	n := len(wantLines)
+	if len(gotLines) > n {
+		n = len(gotLines)
+	}
+
+	var b strings.Builder
+	b.WriteString("--- want\n+++ got\n")
+	for row := 0; row < n; row++ {
+		switch {
+		case row >= len(gotLines):
+			fmt.Fprintf(&b, "-%s\n", wantLines[row])
+		case row >= len(wantLines):
+			fmt.Fprintf(&b, "+%s\n", gotLines[row])
+		case wantLines[row] == gotLines[row]:
+			fmt.Fprintf(&b, " %s\n", wantLines[row])
+		default:
+			fmt.Fprintf(&b, "-%s\n+%s\n", wantLines[row], gotLines[row])
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,120 @@
+package vtermtest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFirstMismatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		want     string
+		got      string
+		contains []string
+	}{
+		{
+			name:     "equal",
+			want:     "abc\ndef",
+			got:      "abc\ndef",
+			contains: nil,
+		},
+		{
+			name:     "differs mid line",
+			want:     "abc\ndef",
+			got:      "abc\ndxf",
+			contains: []string{"row 1, col 1", "\n       ^"},
+		},
+		{
+			name:     "missing line",
+			want:     "abc\ndef",
+			got:      "abc",
+			contains: []string{"row 1, col 0"},
+		},
+		{
+			name:     "wide characters before difference",
+			want:     "日本a",
+			got:      "日本b",
+			contains: []string{"row 0, col 4", "\n          ^"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := firstMismatch(tt.want, tt.got)
+			if tt.contains == nil {
+				if got != "" {
+					t.Errorf("expected no mismatch, got %q", got)
+				}
+				return
+			}
+			for _, c := range tt.contains {
+				if !strings.Contains(got, c) {
+					t.Errorf("expected %q in:\n%s", c, got)
+				}
+			}
+		})
+	}
+}
+
+func TestTemplateMismatch(t *testing.T) {
+	frame := "+------+\n| 42 % |\n+------+\nfooter"
+
+	tests := []struct {
+		name     string
+		template string
+		screen   string
+		contains string
+	}{
+		{"exact", "+------+\n| 42 % |\n+------+", frame, ""},
+		{"dont care cells", "+------+\n|......|\n+------+", frame, ""},
+		{"frame broken", "+------+\n|......|\n+======+", frame, "row 2, col 1"},
+		{"expects content past line end", "+------+ x", frame, "row 0, col 9"},
+		{"wide characters", "日本.", "日本語", ""},
+		{"wide character mismatch", "日語", "日本", "row 0, col 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := templateMismatch(tt.template, tt.screen)
+			if tt.contains == "" {
+				if got != "" {
+					t.Errorf("expected match, got:\n%s", got)
+				}
+				return
+			}
+			if !strings.Contains(got, tt.contains) {
+				t.Errorf("expected %q in:\n%s", tt.contains, got)
+			}
+		})
+	}
+}
+
+func TestDifferingCells(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want int
+	}{
+		{name: "equal", a: "abc\ndef", b: "abc\ndef", want: 0},
+		{name: "one cell", a: "12:00:01", b: "12:00:02", want: 1},
+		{name: "trailing spaces ignored", a: "abc", b: "abc   \n", want: 0},
+		{name: "extra row", a: "abc", b: "abc\nxy", want: 2},
+		{name: "wide character", a: "a日b", b: "a  b", want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := differingCells(tt.a, tt.b); got != tt.want {
+				t.Errorf("differingCells(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLineDiff(t *testing.T) {
+	got := lineDiff("title\nfoo\nbar", "title\nfoo!\nbar\nbaz")
+	want := "--- want\n+++ got\n title\n-foo\n+foo!\n bar\n+baz"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -40,53 +40,30 @@ func TestGetCursorPosition(t *testing.T) {
 }
 
 func TestGetCursorPositionAfterMovement(t *testing.T) {
-	emu := New(24, 80).Command("bash", "-c", "stty raw -echo; cat")
+	emu := New(24, 80).Command("bash", "-c", "stty raw -echo; printf READY; cat")
 	t.Cleanup(func() { _ = emu.Close() })
 
 	if err := emu.Start(context.Background()); err != nil {
 		t.Fatalf("start: %v", err)
 	}
 
-	// Wait for initialization
-	time.Sleep(100 * time.Millisecond)
+	// Wait until the terminal is in raw mode so the input is echoed once, by cat
+	if err := emu.WaitFor("READY", 5*time.Second); err != nil {
+		t.Fatalf("wait for ready marker: %v", err)
+	}
+	start := len("READY") + 1
 
-	// Type some text
+	// Type some text; cat echoes it back
 	if err := emu.KeyPress(keys.Text("test")); err != nil {
 		t.Fatalf("send text: %v", err)
 	}
-
-	// Wait for text to appear
-	time.Sleep(50 * time.Millisecond)
-
-	// Get position after typing "test"
-	row1, col1, err := emu.GetCursorPosition()
-	if err != nil {
-		t.Fatalf("GetCursorPosition after text: %v", err)
-	}
-	t.Logf("Position after 'test': row=%d, col=%d", row1, col1)
+	emu.AssertCursorAt(t, 1, start+4)
 
 	// Move cursor left twice
 	if err := emu.KeyPress(keys.Left, keys.Left); err != nil {
 		t.Fatalf("send left keys: %v", err)
 	}
-
-	// Wait for cursor movement
-	time.Sleep(50 * time.Millisecond)
-
-	// Get position after moving left
-	row2, col2, err := emu.GetCursorPosition()
-	if err != nil {
-		t.Fatalf("GetCursorPosition after movement: %v", err)
-	}
-	t.Logf("Position after Left x2: row=%d, col=%d", row2, col2)
-
-	// Cursor should have moved left by 2 columns
-	if row1 != row2 {
-		t.Errorf("Row changed unexpectedly: %d -> %d", row1, row2)
-	}
-	if col2 != col1-2 {
-		t.Errorf("Expected col to decrease by 2 (from %d to %d), got: %d", col1, col1-2, col2)
-	}
+	emu.AssertCursorAt(t, 1, start+2)
 }
 
 func TestDSRSequenceInKeys(t *testing.T) {
@@ -95,4 +72,13 @@ func TestDSRSequenceInKeys(t *testing.T) {
 	if string(keys.DSR) != string(expected) {
 		t.Errorf("DSR sequence mismatch. Got %v, want %v", keys.DSR, expected)
 	}
-}
\ No newline at end of file
+}
+
+func TestFocusSequencesInKeys(t *testing.T) {
+	if string(keys.FocusIn) != "\x1b[I" {
+		t.Errorf("FocusIn sequence mismatch. Got %v", keys.FocusIn)
+	}
+	if string(keys.FocusOut) != "\x1b[O" {
+		t.Errorf("FocusOut sequence mismatch. Got %v", keys.FocusOut)
+	}
+}
@@ -0,0 +1,19 @@
+package vtermtest
+
+import "bytes"
+
+// WithDumbTerminal emulates a dumb terminal: the command runs with TERM=dumb, and
+// escape sequences in its output are not interpreted but shown literally, with ESC
+// rendered as "^[". Programs that degrade gracefully print plain text, while any
+// leaked cursor movement or colors remain visible on the screen for assertions.
+// Plain control characters (CR, LF, BS, TAB, BEL) still behave as usual.
+// TERM is not set for commands given with WithCmd. Returns self for method chaining.
+func (e *Emulator) WithDumbTerminal() *Emulator {
+	e.dumb = true
+	return e
+}
+
+// visibleEscapes replaces each ESC in data with "^[".
+func visibleEscapes(data []byte) []byte {
+	return bytes.ReplaceAll(data, []byte{0x1B}, []byte("^["))
+}
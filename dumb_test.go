@@ -0,0 +1,24 @@
+package vtermtest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/c-bata/vtermtest"
+)
+
+func TestWithDumbTerminal(t *testing.T) {
+	emu := vtermtest.New(4, 40).
+		Command("sh", "-c", "echo \"TERM=$TERM\"; printf 'a\\033[2Jb\\n'; sleep 5").
+		Env("LANG=C.UTF-8", "TERM=xterm").
+		WithDumbTerminal()
+	t.Cleanup(func() { _ = emu.Close() })
+
+	if err := emu.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	emu.AssertLineEqual(t, 0, "TERM=dumb")
+	// The clear screen sequence is shown instead of clearing the first line
+	emu.AssertLineEqual(t, 1, "a^[[2Jb")
+}
@@ -2,12 +2,14 @@
 package vtermtest
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -17,6 +19,12 @@ import (
 	libvterm "github.com/mattn/go-libvterm"
 )
 
+// Default wait settings for the DSL's <WaitStable> and <WaitFor> tags
+const (
+	defaultStableQuiet = 100 * time.Millisecond
+	defaultWaitTimeout = 5 * time.Second
+)
+
 // Emulator represents a terminal emulator for testing interactive programs.
 // It creates a PTY, launches a process, and uses libvterm to emulate terminal behavior.
 type Emulator struct {
@@ -33,17 +41,141 @@ type Emulator struct {
 	mu           sync.Mutex
 	lastActivity time.Time
 	readerDone   chan struct{}
+	finalScreen  string
+
+	// Closed once the program has exited and been reaped, with the result of
+	// cmd.Wait
+	processDone chan struct{}
+	waitErr     error
+
+	// Screen text cache, invalidated whenever the generation changes
+	generation uint64
+	cachedGen  uint64
+	cachedText string
+	cacheValid bool
 
 	commandPath string
 	commandArgs []string
 	env         []string
 	dir         string
+	customCmd   *exec.Cmd
 
 	assertCfg assertConfig
 
 	// Raw bytes collection
 	collectRawBytes bool
 	rawBytes        []byte
+	rawChunks       []rawChunk
+	startTime       time.Time
+
+	// Input bytes collection, guarded by sentMu since writes happen
+	// from libvterm callbacks while mu is held
+	collectSentBytes bool
+	sentMu           sync.Mutex
+	sentBytes        []byte
+
+	// Reactive input channel, started lazily by InputChan; inputStop and
+	// inputDone are guarded by mu
+	inputOnce sync.Once
+	inputCh   chan []byte
+	inputStop chan struct{}
+	inputDone chan struct{}
+	inputErr  error
+
+	// Per-row time of last modification, updated from damage callbacks
+	lineChanged map[int]time.Time
+
+	// DEC private modes requested by the program
+	modes modeTracker
+
+	// Defaults for the DSL's <WaitStable> and <WaitFor> (zero means built-in)
+	stableQuiet time.Duration
+	waitTimeout time.Duration
+
+	// Per-key byte overrides for named keys
+	keyOverrides []keyOverride
+
+	// Minimum time between key sequences sent by KeyPress, and when the last
+	// one was sent, guarded by keyMu
+	keyPressDelay time.Duration
+	keyMu         sync.Mutex
+	lastKeyPress  time.Time
+
+	// Use a soft reset instead of a hard one in Start and Reset
+	softReset bool
+
+	// Interpret output as single bytes instead of UTF-8
+	disableUTF8 bool
+
+	// SGR rewriting for color resolution, WithPalette and WithConcealHandling
+	sgr *sgrRewriter
+
+	// How concealed (SGR 8) cells appear in screen text
+	conceal ConcealMode
+
+	// Lines scrolled off the top of the screen, and those kept when enabled.
+	// shadow is a copy of the screen rows, so scrolled off rows can be kept
+	// after libvterm moved its cells; shadowDirty marks rows to re-read.
+	scrolledLines     int
+	scrollbackEnabled bool
+	scrollbackMax     int
+	scrollback        []string
+	shadow            []string
+	shadowDirty       []bool
+
+	// Run with TERM=dumb and show escape sequences literally
+	dumb bool
+
+	// Row separator used by GetScreenText (default "\n")
+	lineEnding string
+
+	// REPL prompt stripped by GetLineStrippingPrompt
+	promptPrefix  string
+	promptPattern *regexp.Regexp
+
+	// Answerback message sent in reply to ENQ (disabled when empty)
+	answerback string
+
+	// Full resets (RIS) sent by the program
+	ris             risTracker
+	resetCount      int
+	onTerminalReset func()
+
+	// Window size queries and the pixel size reported for CSI 14 t
+	winops      winopsTracker
+	pixelWidth  int
+	pixelHeight int
+
+	// Bells (BEL) rung by the program since Start or Reset
+	bellCount int
+
+	// OSC sequences from the program, the window title and OSC 8 hyperlinks
+	osc       oscTracker
+	title     string
+	openLink  *openLink
+	linkSpans []linkSpan
+
+	// PTY traffic tracing
+	trace tracer
+
+	// Stable frame capture
+	captureFrames bool
+	frames        []string
+
+	// Rendered frame counting for CountFramesDuring
+	countingFrames bool
+	frameCount     int
+	lastFrame      string
+
+	// Screen change callback, the last screen passed to it, and whether libvterm
+	// reported damage since the last read
+	onScreenChange func(screen string)
+	notifiedScreen string
+	damaged        bool
+
+	// Damage reported by libvterm, for WaitStable
+	damageCount uint64
+	lastDamage  time.Time
 }
 
 // New creates a new Emulator with the specified terminal dimensions.
@@ -53,16 +185,186 @@ func New(rows, cols uint16) *Emulator {
 		rows:       rows,
 		cols:       cols,
 		readerDone: make(chan struct{}),
+		sgr:        &sgrRewriter{resolveIndexed: true},
 	}
 }
 
 // EnableRawBytesCollection enables collection of raw bytes from PTY.
 // When enabled, all bytes read from PTY are stored and can be retrieved with GetRawBytes().
+// Each chunk is also timestamped for ExportAsciicast.
 func (e *Emulator) EnableRawBytesCollection() *Emulator {
 	e.collectRawBytes = true
 	return e
 }
 
+// EnableSentBytesCollection enables collection of bytes written to the PTY.
+// When enabled, all input sent to the program can be retrieved with SentBytes().
+func (e *Emulator) EnableSentBytesCollection() *Emulator {
+	e.collectSentBytes = true
+	return e
+}
+
+// EnableFrameCapture enables recording of stable frames.
+// Each time WaitStable detects a stable screen, its text is appended to the
+// frame list, which can be retrieved with GetFrames(). Consecutive identical
+// frames are recorded only once.
+func (e *Emulator) EnableFrameCapture() *Emulator {
+	e.captureFrames = true
+	return e
+}
+
+// WithInitialReset sets whether Start and Reset perform a hard or soft terminal reset
+// (hard by default). Both restore terminal modes, scroll regions, tab stops, character
+// sets and pen attributes to their defaults; a hard reset additionally clears the
+// screen and moves the cursor home, while a soft reset keeps screen contents and
+// cursor position. Returns self for method chaining.
+func (e *Emulator) WithInitialReset(hard bool) *Emulator {
+	e.softReset = !hard
+	return e
+}
+
+// WithUTF8 sets whether libvterm decodes the program's output as UTF-8 (the
+// default, matching LANG=C.UTF-8) or as single-byte characters, where each byte
+// above 0x7F is its own Latin-1 character. Returns self for method chaining.
+func (e *Emulator) WithUTF8(enable bool) *Emulator {
+	e.disableUTF8 = !enable
+	return e
+}
+
+// WithDefaultStableQuiet sets how long the screen must be unchanged for the DSL's
+// <WaitStable>, MeasureResponse (and AssertScreenMatchesGolden when updating) to
+// consider it stable. The default is 100ms. Returns self for method chaining.
+func (e *Emulator) WithDefaultStableQuiet(d time.Duration) *Emulator {
+	e.stableQuiet = d
+	return e
+}
+
+// WithDefaultWaitTimeout sets how long the DSL's <WaitStable> and <WaitFor text>,
+// MeasureResponse, ResizeSequence and Drain wait before failing, e.g. to give
+// slow commands in CI more time without changing the scripts. The default is
+// 5s. Returns self for method chaining.
+func (e *Emulator) WithDefaultWaitTimeout(d time.Duration) *Emulator {
+	e.waitTimeout = d
+	return e
+}
+
+func (e *Emulator) getStableQuiet() time.Duration {
+	if e.stableQuiet > 0 {
+		return e.stableQuiet
+	}
+	return defaultStableQuiet
+}
+
+func (e *Emulator) getWaitTimeout() time.Duration {
+	if e.waitTimeout > 0 {
+		return e.waitTimeout
+	}
+	return defaultWaitTimeout
+}
+
+// Reset resets the running terminal emulation as configured by WithInitialReset.
+// The program is not notified; only the emulator state changes. The bell count
+// is reset as well.
+func (e *Emulator) Reset() error {
+	if e.screen == nil {
+		return errors.New("emulator not started")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.screen.Reset(!e.softReset)
+	e.screen.Flush()
+	e.modes = modeTracker{}
+	e.bellCount = 0
+	e.lastActivity = time.Now()
+	e.generation++
+	return nil
+}
+
+// WithAnswerback makes the emulator reply with s whenever the program writes
+// ENQ (0x05). Answerback is off by default, in which case ENQ is ignored.
+// Use keys.ENQ to send ENQ to the program instead.
+func (e *Emulator) WithAnswerback(s string) *Emulator {
+	e.answerback = s
+	return e
+}
+
+// WithKeyOverride remaps the bytes sent for a named key, for programs that expect
+// a different encoding than the default (e.g. "Home" as ESC[1~). name is a DSL
+// key name such as "Home", "Up" or "C-a" (case-insensitive); unknown names are
+// ignored. KeyPress, and so the DSL, sends b for the named key: the keys package
+// value for it (e.g. keys.Home) or its DSL tag (<Home>). Other input with the
+// same bytes, such as keys.Text("\r") for "Enter", or <C-m> and keys.CtrlM, is
+// sent unchanged. Returns self for method chaining.
+func (e *Emulator) WithKeyOverride(name string, b []byte) *Emulator {
+	key, err := keys.Lookup(name)
+	if err != nil {
+		return e
+	}
+	for i := range e.keyOverrides {
+		if sameKey(e.keyOverrides[i].key, key) {
+			e.keyOverrides[i].b = b
+			return e
+		}
+	}
+	e.keyOverrides = append(e.keyOverrides, keyOverride{key: key, b: b})
+	return e
+}
+
+// keyOverride replaces the bytes sent for a named key.
+type keyOverride struct {
+	key []byte // the keys package value for the key, as returned by keys.Lookup
+	b   []byte
+}
+
+// sameKey reports whether a and b are the same named key value, rather than
+// merely equal bytes.
+func sameKey(a, b []byte) bool {
+	return len(a) > 0 && len(a) == len(b) && &a[0] == &b[0]
+}
+
+// WithKeyPressDelay makes KeyPress, and so the DSL, wait until d has passed since
+// the previous key sequence before writing the next one, to simulate human typing
+// speed for programs that debounce or drop fast input. Each argument to KeyPress
+// and each DSL key or text run is one sequence. The default is zero (no delay).
+// Returns self for method chaining.
+func (e *Emulator) WithKeyPressDelay(d time.Duration) *Emulator {
+	e.keyPressDelay = d
+	return e
+}
+
+// writeKey writes a key sequence to the PTY, first waiting out the remainder of
+// the key press delay since the previous sequence.
+func (e *Emulator) writeKey(key []byte) error {
+	if e.keyPressDelay <= 0 {
+		_, err := e.writePTY(key)
+		return err
+	}
+
+	e.keyMu.Lock()
+	defer e.keyMu.Unlock()
+
+	if !e.lastKeyPress.IsZero() {
+		if wait := e.keyPressDelay - time.Since(e.lastKeyPress); wait > 0 {
+			time.Sleep(wait)
+		}
+	}
+	_, err := e.writePTY(key)
+	e.lastKeyPress = time.Now()
+	return err
+}
+
+// overrideKey returns the override for key if it is an overridden named key.
+func (e *Emulator) overrideKey(key []byte) ([]byte, bool) {
+	for _, o := range e.keyOverrides {
+		if sameKey(o.key, key) {
+			return o.b, true
+		}
+	}
+	return nil, false
+}
+
 // Command sets the command to execute. Returns self for method chaining.
 func (e *Emulator) Command(path string, args ...string) *Emulator {
 	e.commandPath = path
@@ -83,19 +385,47 @@ func (e *Emulator) Dir(dir string) *Emulator {
 	return e
 }
 
+// WithCmd uses a caller-supplied command instead of building one from
+// Command, Env and Dir, which are ignored when cmd is set. Returns self for method chaining.
+//
+// The library attaches the PTY on Start: Stdin, Stdout and Stderr are set to the
+// PTY when nil, and SysProcAttr.Setsid and SysProcAttr.Setctty are forced to true
+// (SysProcAttr is allocated if nil). Since the process leads a new session, Start
+// returns an error if SysProcAttr.Setpgid is set. Other fields such as Env, Dir,
+// ExtraFiles and the remaining SysProcAttr settings are left untouched; in
+// particular WithDumbTerminal does not set TERM=dumb in cmd.Env. The context
+// passed to Start is not bound to cmd; use exec.CommandContext when building it
+// if needed.
+func (e *Emulator) WithCmd(cmd *exec.Cmd) *Emulator {
+	e.customCmd = cmd
+	return e
+}
+
 // Start launches the command in a PTY and begins terminal emulation.
 // The context can be used to control the lifetime of the process.
 func (e *Emulator) Start(ctx context.Context) error {
-	if e.commandPath == "" {
-		return errors.New("no command specified")
-	}
+	if e.customCmd != nil {
+		if err := checkSysProcAttr(e.customCmd); err != nil {
+			return err
+		}
+		e.cmd = e.customCmd
+	} else {
+		if e.commandPath == "" {
+			return errors.New("no command specified")
+		}
 
-	e.cmd = exec.CommandContext(ctx, e.commandPath, e.commandArgs...)
-	if len(e.env) > 0 {
-		e.cmd.Env = append(os.Environ(), e.env...)
-	}
-	if e.dir != "" {
-		e.cmd.Dir = e.dir
+		e.cmd = exec.CommandContext(ctx, e.commandPath, e.commandArgs...)
+		if len(e.env) > 0 || e.dumb {
+			env := append(os.Environ(), e.env...)
+			if e.dumb {
+				// Appended last so it takes precedence over Env
+				env = append(env, "TERM=dumb")
+			}
+			e.cmd.Env = env
+		}
+		if e.dir != "" {
+			e.cmd.Dir = e.dir
+		}
 	}
 
 	ptmx, err := pty.StartWithSize(e.cmd, &pty.Winsize{
@@ -106,18 +436,26 @@ func (e *Emulator) Start(ctx context.Context) error {
 		return err
 	}
 	e.ptmx = ptmx
+	e.startTime = time.Now()
+	e.processDone = make(chan struct{})
+	go e.waitProcess()
 
 	e.vt = libvterm.New(int(e.rows), int(e.cols))
+	e.vt.SetUTF8(!e.disableUTF8)
 	e.screen = e.vt.ObtainScreen()
 	e.state = e.vt.ObtainState()
-	e.screen.Reset(true)
+	e.screen.Reset(!e.softReset)
+	e.screen.OnDamage = e.onDamage
+	e.screen.OnMoveRect = e.onMoveRect
+	e.screen.OnBell = e.onBell
+	e.notifiedScreen = e.screenText()
 
 	// Set output callback to receive terminal responses (DSR, etc)
 	// This writes DSR responses back to PTY so programs can read them
 	e.vt.SetOutputCallback(func(data []byte) {
 		// Write DSR response back to PTY so the program can read it
 		if e.ptmx != nil {
-			e.ptmx.Write(data)
+			e.writePTY(data)
 		}
 	})
 
@@ -133,32 +471,136 @@ func (e *Emulator) readLoop() {
 	for {
 		n, err := e.ptmx.Read(buf)
 		if n > 0 {
+			e.trace.log("<<", buf[:n])
 			e.mu.Lock()
 			// Collect raw bytes if enabled
 			if e.collectRawBytes {
 				e.rawBytes = append(e.rawBytes, buf[:n]...)
+				e.rawChunks = append(e.rawChunks, rawChunk{
+					at:   time.Since(e.startTime),
+					data: append([]byte(nil), buf[:n]...),
+				})
 			}
-			_, writeErr := e.vt.Write(buf[:n])
+			data := buf[:n]
+			var oscCmds []oscCommand
+			var replies [][]byte
+			resets := 0
+			if e.dumb {
+				// A dumb terminal neither tracks modes nor answers queries
+				data = visibleEscapes(data)
+			} else {
+				e.modes.feed(data)
+				resets = e.ris.feed(data)
+				e.resetCount += resets
+				for _, query := range e.winops.feed(data) {
+					replies = append(replies, e.winopsReply(query))
+				}
+				if e.sgr != nil {
+					data = e.sgr.feed(data)
+				}
+				oscCmds = e.osc.feed(data)
+				e.handleOSC(oscCmds)
+			}
+			writeErr := e.writeVTWithLinks(data, oscCmds)
 			if writeErr == nil {
 				e.screen.Flush()
 			}
 			e.lastActivity = time.Now()
+			e.generation++
+			if e.countingFrames {
+				e.countFrame()
+			}
+			changed, screenChanged := e.screenChange()
 			e.mu.Unlock()
+
+			if screenChanged {
+				e.onScreenChange(changed)
+			}
+
+			if e.onTerminalReset != nil {
+				for i := 0; i < resets; i++ {
+					e.onTerminalReset()
+				}
+			}
+
+			// Reply to window size queries, which libvterm does not answer
+			for _, reply := range replies {
+				e.writePTY(reply)
+			}
+
+			// Reply to ENQ with the configured answerback
+			if e.answerback != "" {
+				for i := 0; i < bytes.Count(buf[:n], keys.ENQ); i++ {
+					e.writePTY([]byte(e.answerback))
+				}
+			}
 		}
 		if err != nil {
 			if err != io.EOF {
 				// Log error if needed
 			}
+			// Flush pending damage and keep the final frame so it
+			// stays readable after the program exits
+			e.mu.Lock()
+			if e.screen != nil {
+				// A sequence held back by the SGR rewriter will never be completed
+				if e.sgr != nil && !e.dumb {
+					if rest := e.sgr.flush(); len(rest) > 0 {
+						e.writeVT(rest)
+					}
+				}
+				e.screen.Flush()
+				e.finalScreen = e.screenText()
+			}
+			e.mu.Unlock()
 			break
 		}
 	}
 }
 
+// onDamage records the change time of damaged rows.
+// It is called by libvterm while e.mu is held by the writer.
+func (e *Emulator) onDamage(rect *libvterm.Rect) int {
+	e.noteDamage(rect)
+	if e.scrollbackEnabled {
+		e.markShadowDirty(rect.StartRow(), rect.EndRow())
+	}
+	return 1
+}
+
+// noteDamage records that the rows of rect changed. The caller must hold e.mu.
+func (e *Emulator) noteDamage(rect *libvterm.Rect) {
+	e.damaged = true
+	now := time.Now()
+	e.damageCount++
+	e.lastDamage = now
+	if e.lineChanged == nil {
+		e.lineChanged = make(map[int]time.Time)
+	}
+	for row := rect.StartRow(); row < rect.EndRow(); row++ {
+		e.lineChanged[row] = now
+	}
+}
+
 // Close terminates the process and cleans up resources.
 // It closes the PTY, kills the process if still running, and waits for cleanup.
+// On Unix the whole process group is killed if the program is still running, so
+// children spawned by the command (e.g. both sides of a pipeline in "sh -c") do
+// not outlive the test. Once the program has been reaped its group ID may be
+// reused, so the group is left alone.
 func (e *Emulator) Close() error {
 	var errs []error
 
+	// Stop the input channel writer; closing the PTY below unblocks a pending write.
+	// inputStop is cleared so a second Close does not close it again.
+	e.mu.Lock()
+	inputStop, inputDone := e.inputStop, e.inputDone
+	e.inputStop = nil
+	e.mu.Unlock()
+	if inputStop != nil {
+		close(inputStop)
+	}
+
 	// Close PTY
 	if e.ptmx != nil {
 		if err := e.ptmx.Close(); err != nil {
@@ -166,16 +608,22 @@ func (e *Emulator) Close() error {
 		}
 	}
 
-	// Kill process if still running
-	if e.cmd != nil && e.cmd.Process != nil {
-		if err := e.cmd.Process.Kill(); err != nil {
-			// Process might already be dead, which is OK
-			if !strings.Contains(err.Error(), "process already finished") {
-				errs = append(errs, err)
+	// Kill process and its descendants if still running
+	if e.processDone != nil {
+		select {
+		case <-e.processDone:
+			// Already reaped; its process group ID may have been reused
+		default:
+			if err := killProcessGroup(e.cmd.Process); err != nil {
+				// Process might already be dead, which is OK
+				if !errors.Is(err, os.ErrProcessDone) {
+					errs = append(errs, err)
+				}
 			}
 		}
 		// Wait for process to exit
-		if err := e.cmd.Wait(); err != nil {
+		<-e.processDone
+		if err := e.waitErr; err != nil {
 			// Ignore "signal: killed" errors
 			if !strings.Contains(err.Error(), "signal: killed") {
 				errs = append(errs, err)
@@ -190,8 +638,25 @@ func (e *Emulator) Close() error {
 		errs = append(errs, errors.New("timeout waiting for reader to finish"))
 	}
 
-	// Close libvterm
+	// Wait for the input channel writer to finish
+	if inputDone != nil {
+		select {
+		case <-inputDone:
+			if e.inputErr != nil {
+				errs = append(errs, fmt.Errorf("input channel: %w", e.inputErr))
+			}
+		case <-time.After(2 * time.Second):
+			errs = append(errs, errors.New("timeout waiting for input writer to finish"))
+		}
+	}
+
+	// Close libvterm, keeping the last frame for FinalScreen
 	if e.vt != nil {
+		e.mu.Lock()
+		e.finalScreen = e.screenText()
+		e.screen = nil
+		e.state = nil
+		e.mu.Unlock()
 		if err := e.vt.Close(); err != nil {
 			errs = append(errs, err)
 		}
@@ -203,21 +668,110 @@ func (e *Emulator) Close() error {
 	return nil
 }
 
+// stateErr explains why libvterm's state is unavailable: the emulator was
+// either never started or has been closed. The caller must hold e.mu.
+func (e *Emulator) stateErr() error {
+	if e.vt != nil {
+		return errors.New("emulator closed")
+	}
+	return errors.New("emulator not started")
+}
+
+// Done returns a channel that is closed when the program's output has ended: the
+// program exited (along with any children holding the terminal) and everything it
+// wrote has been rendered, so the screen can be asserted on right away. It is also
+// closed by Close. Use Wait for the exit code.
+//
+// Call Done after Start. The channel is created by New and is never nil, but
+// without Start there is no output to end, so receiving from it blocks forever.
+func (e *Emulator) Done() <-chan struct{} {
+	return e.readerDone
+}
+
+// FinalScreen returns the last screen the program rendered before its output ended.
+// It waits until all PTY output has been consumed (the program exited or Close was
+// called), so the final frame of short-lived commands is captured reliably.
+// The result remains available after Close.
+func (e *Emulator) FinalScreen() (string, error) {
+	if e.ptmx == nil {
+		return "", errors.New("emulator not started")
+	}
+
+	select {
+	case <-e.readerDone:
+	case <-time.After(5 * time.Second):
+		return "", errors.New("timeout waiting for program output to end")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.screen != nil {
+		return e.screenText(), nil
+	}
+	return e.finalScreen, nil
+}
+
 // KeyPress sends keystrokes to the terminal.
 // Use the keys package for special keys (e.g., keys.Tab, keys.Enter).
+// Cursor keys are sent in application mode (ESC O x) while the program has
+// enabled application cursor keys (DECSET 1).
 func (e *Emulator) KeyPress(keys ...[]byte) error {
 	if e.ptmx == nil {
 		return errors.New("emulator not started")
 	}
 
+	appCursor := e.ApplicationCursorKeys()
 	for _, key := range keys {
-		if _, err := e.ptmx.Write(key); err != nil {
+		if override, ok := e.overrideKey(key); ok {
+			key = override
+		} else if appCursor {
+			if appKey := applicationCursorKey(key); appKey != nil {
+				key = appKey
+			}
+		}
+		if err := e.writeKey(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SendRunes writes each rune's UTF-8 encoding as a separate PTY write, sleeping
+// delay between runes (zero means no delay). Unlike KeyPress(keys.Text(s)), which
+// writes the whole string at once, this feeds input one code point at a time,
+// so combining marks and wide characters arrive exactly as split in rs.
+func (e *Emulator) SendRunes(rs []rune, delay time.Duration) error {
+	for i, r := range rs {
+		if i > 0 && delay > 0 {
+			time.Sleep(delay)
+		}
+		if err := e.KeyPress([]byte(string(r))); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// WriteRaw writes b to the PTY verbatim in one write and returns the number of
+// bytes written, e.g. to replay a sequence captured from a real session. It
+// bypasses the DSL, key overrides, application cursor key translation and the
+// key press delay; the bytes are still traced and collected by SentBytes.
+func (e *Emulator) WriteRaw(b []byte) (int, error) {
+	if e.ptmx == nil {
+		return 0, errors.New("emulator not started")
+	}
+	return e.writePTY(b)
+}
+
+// SendFocus sends a focus in (ESC[I) or focus out (ESC[O) event to the program.
+// Programs only interpret these when they have enabled focus reporting (DECSET 1004).
+func (e *Emulator) SendFocus(focused bool) error {
+	if focused {
+		return e.KeyPress(keys.FocusIn)
+	}
+	return e.KeyPress(keys.FocusOut)
+}
+
 // KeyPressString sends keystrokes using DSL notation.
 // Example: "hello<Tab>world<C-c>" sends "hello", Tab key, "world", then Ctrl-C.
 // Special DSL: <WaitStable> waits for screen to stabilize.
@@ -229,71 +783,236 @@ func (e *Emulator) KeyPressString(dsl string) error {
 // KeyPressStringWithOptions sends keystrokes using DSL notation with custom tag delimiters.
 // Example with options {TagStart: '[', TagEnd: ']'}: "hello[Tab]world[C-c]"
 func (e *Emulator) KeyPressStringWithOptions(dsl string, opts keys.ParseOptions) error {
-	parsedKeys, err := keys.ParseWithOptions(dsl, opts)
+	parsedKeys, err := e.parseDSL(dsl, opts)
 	if err != nil {
-		return fmt.Errorf("parse DSL: %w", err)
+		return err
 	}
 
+	// Failures are annotated with the most recent <Label name>
+	label := ""
 	for _, key := range parsedKeys {
-		keyStr := string(key)
-		if keyStr == "__WAITSTABLE__" {
-			if !e.WaitStable(100*time.Millisecond, 5*time.Second) {
-				return fmt.Errorf("screen did not stabilize")
-			}
-		} else if strings.HasPrefix(keyStr, "__WAITFOR__") {
-			text := keyStr[11:] // Remove "__WAITFOR__" prefix
-			if err := e.WaitFor(text, 5*time.Second); err != nil {
-				return err
-			}
-		} else {
-			if err := e.KeyPress(key); err != nil {
-				return err
+		if _, err := e.runDSLStep(key, &label); err != nil {
+			if label != "" {
+				return fmt.Errorf("step %q: %w", label, err)
 			}
+			return err
 		}
 	}
 	return nil
 }
 
+// parseDSL parses dsl. The emulator's key overrides are not applied here but
+// by KeyPress when the parsed keys are sent.
+func (e *Emulator) parseDSL(dsl string, opts keys.ParseOptions) ([][]byte, error) {
+	parsedKeys, err := keys.ParseWithOptions(dsl, opts)
+	if err != nil {
+		return nil, fmt.Errorf("parse DSL: %w", err)
+	}
+	return parsedKeys, nil
+}
+
+// runDSLStep performs one parsed DSL step: a <WaitStable>, <WaitFor>, <Sleep> or
+// <Label> directive, or a key press. <Label> steps update *label. Reports whether the
+// step sent input to the program.
+func (e *Emulator) runDSLStep(key []byte, label *string) (bool, error) {
+	keyStr := string(key)
+	switch {
+	case keyStr == "__WAITSTABLE__":
+		if !e.WaitStable(e.getStableQuiet(), e.getWaitTimeout()) {
+			return false, fmt.Errorf("screen did not stabilize")
+		}
+		return false, nil
+	case strings.HasPrefix(keyStr, "__WAITFOR__"):
+		text := keyStr[11:] // Remove "__WAITFOR__" prefix
+		return false, e.WaitFor(text, e.getWaitTimeout())
+	case strings.HasPrefix(keyStr, "__SLEEP__"):
+		d, err := time.ParseDuration(keyStr[9:]) // Remove "__SLEEP__" prefix
+		if err != nil {
+			return false, fmt.Errorf("invalid sleep duration: %w", err)
+		}
+		time.Sleep(d)
+		return false, nil
+	case strings.HasPrefix(keyStr, "__LABEL__"):
+		*label = keyStr[9:] // Remove "__LABEL__" prefix
+		return false, nil
+	}
+	return true, e.KeyPress(key)
+}
+
 // WaitStable waits until the screen output is stable (no changes for 'quiet' duration).
 // Returns true if stable within timeout, false if timeout exceeded.
 // quiet: duration of inactivity to consider stable
 // timeout: maximum time to wait
+//
+// Instead of polling, it sleeps until 'quiet' has passed since libvterm last
+// reported damage, and renders the screen again only if damage arrived in the
+// meantime. Damage that leaves the text unchanged (identical redraws, color
+// changes) does not restart the quiet period.
 func (e *Emulator) WaitStable(quiet, timeout time.Duration) bool {
 	deadline := time.Now().Add(timeout)
-	var lastScreen string
-	var stableStart time.Time
 
-	// Get initial screen content
-	screen, err := e.GetScreenText()
+	e.mu.Lock()
+	seen := e.damageCount
+	e.mu.Unlock()
+	lastScreen, err := e.getScreenText()
 	if err != nil {
 		return false
 	}
-	lastScreen = screen
-	stableStart = time.Now()
+	lastChange := time.Now()
 
 	for {
-		if time.Now().After(deadline) {
-			return false
+		wake := lastChange.Add(quiet)
+		if wake.After(deadline) {
+			wake = deadline
 		}
+		time.Sleep(time.Until(wake))
 
-		time.Sleep(10 * time.Millisecond)
+		e.mu.Lock()
+		damageCount, lastDamage := e.damageCount, e.lastDamage
+		e.mu.Unlock()
+		if damageCount != seen {
+			seen = damageCount
+			currentScreen, err := e.getScreenText()
+			if err != nil {
+				return false
+			}
+			if currentScreen != lastScreen {
+				// Screen content changed, restart the quiet period from the change
+				lastScreen = currentScreen
+				lastChange = lastDamage
+			}
+		}
 
-		// Get current screen content
-		currentScreen, err := e.GetScreenText()
-		if err != nil {
+		if time.Since(lastChange) >= quiet {
+			e.recordFrame(lastScreen)
+			return true
+		}
+		if !time.Now().Before(deadline) {
 			return false
 		}
+	}
+}
 
-		if currentScreen == lastScreen {
-			// Screen content hasn't changed
-			if time.Since(stableStart) >= quiet {
-				return true
-			}
-		} else {
-			// Screen content changed, reset stable timer
-			lastScreen = currentScreen
-			stableStart = time.Now()
+// MeasureResponse sends keys and measures how long the program took to respond,
+// i.e. the time from sending until the last output before the screen became stable.
+// Stability uses the defaults set by WithDefaultStableQuiet and WithDefaultWaitTimeout.
+// Returns an error if the screen does not stabilize or no output followed the keys.
+func (e *Emulator) MeasureResponse(keys ...[]byte) (time.Duration, error) {
+	start := time.Now()
+	if err := e.KeyPress(keys...); err != nil {
+		return 0, err
+	}
+
+	if !e.WaitStable(e.getStableQuiet(), e.getWaitTimeout()) {
+		return 0, fmt.Errorf("screen did not stabilize")
+	}
+
+	e.mu.Lock()
+	last := e.lastActivity
+	e.mu.Unlock()
+
+	if last.Before(start) {
+		return 0, errors.New("no output after keys were sent")
+	}
+	return last.Sub(start), nil
+}
+
+// Drain waits until no output has been read from the PTY and libvterm has
+// reported no damage for 'quiet', so any backlog from a previous test phase has
+// been consumed and rendered. It then moves the last activity time to now, so
+// MeasureResponse only measures output that follows. Returns an error if output
+// does not go quiet within the default wait timeout (see WithDefaultWaitTimeout).
+func (e *Emulator) Drain(quiet time.Duration) error {
+	deadline := time.Now().Add(e.getWaitTimeout())
+
+	for {
+		e.mu.Lock()
+		if time.Since(e.lastActivity) >= quiet && time.Since(e.lastDamage) >= quiet {
+			e.lastActivity = time.Now()
+			e.mu.Unlock()
+			return nil
 		}
+		e.mu.Unlock()
+
+		if time.Now().After(deadline) {
+			return errors.New("output did not go quiet")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// recordFrame appends a stable frame if frame capture is enabled.
+func (e *Emulator) recordFrame(screen string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.captureFrames {
+		return
+	}
+	if n := len(e.frames); n > 0 && e.frames[n-1] == screen {
+		return
+	}
+	e.frames = append(e.frames, screen)
+}
+
+// GetFrames returns the stable frames recorded so far.
+// Frame capture must be enabled with EnableFrameCapture().
+// Returns a copy of the recorded frames.
+func (e *Emulator) GetFrames() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.captureFrames {
+		return nil
+	}
+
+	result := make([]string, len(e.frames))
+	copy(result, e.frames)
+	return result
+}
+
+// CountFramesDuring runs action and returns how many distinct frames the program
+// rendered from the start of action until window has elapsed.
+// A frame is the screen text after libvterm processes one read from the PTY; it is
+// counted only if it differs from the previous frame, so output that leaves the text
+// unchanged (cursor movement, mode switches, identical redraws) is not counted.
+// A single redraw split across several reads may count its intermediate states.
+func (e *Emulator) CountFramesDuring(action func() error, window time.Duration) (int, error) {
+	e.mu.Lock()
+	if e.screen == nil {
+		e.mu.Unlock()
+		return 0, errors.New("emulator not started")
+	}
+	e.countingFrames = true
+	e.frameCount = 0
+	e.lastFrame = e.screenText()
+	e.mu.Unlock()
+
+	defer func() {
+		e.mu.Lock()
+		e.countingFrames = false
+		e.lastFrame = ""
+		e.mu.Unlock()
+	}()
+
+	start := time.Now()
+	if err := action(); err != nil {
+		return 0, fmt.Errorf("action failed: %w", err)
+	}
+	time.Sleep(window - time.Since(start))
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.frameCount, nil
+}
+
+// countFrame counts the current screen if it differs from the last frame.
+// The caller must hold e.mu.
+func (e *Emulator) countFrame() {
+	text := e.screenText()
+	if text != e.lastFrame {
+		e.frameCount++
+		e.lastFrame = text
 	}
 }
 
@@ -301,22 +1020,120 @@ func (e *Emulator) WaitStable(quiet, timeout time.Duration) bool {
 // Returns error if text doesn't appear within timeout.
 // timeout: maximum time to wait for the text to appear
 func (e *Emulator) WaitFor(text string, timeout time.Duration) error {
+	return e.waitForScreen(timeout, func() string {
+		return fmt.Sprintf("text %q not found", text)
+	}, func(screen string) bool {
+		return strings.Contains(screen, text)
+	})
+}
+
+// WaitForRegexp waits until re matches the screen text and returns the match and
+// its submatches, as from FindStringSubmatch. Rows are separated by "\n" and have
+// trailing spaces trimmed; compile re with (?m) for ^ and $ to match at row
+// boundaries, e.g. `(?m)^(\w+)@\w+:~\$$`.
+func (e *Emulator) WaitForRegexp(re *regexp.Regexp, timeout time.Duration) ([]string, error) {
+	var match []string
+	err := e.waitForScreen(timeout, func() string {
+		return fmt.Sprintf("pattern %q not matched", re)
+	}, func(screen string) bool {
+		match = re.FindStringSubmatch(screen)
+		return match != nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return match, nil
+}
+
+// WaitForLine waits until the given row contains substr, ignoring the rest of the
+// screen, e.g. to wait for a status bar. Negative rows count from the bottom as
+// in GetLine. A row out of range is reported immediately instead of at timeout.
+func (e *Emulator) WaitForLine(row int, substr string, timeout time.Duration) error {
+	e.mu.Lock()
+	rows := int(e.rows)
+	e.mu.Unlock()
+	if row >= rows || row < -rows {
+		return fmt.Errorf("row %d out of range for %d rows", row, rows)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		line, err := e.GetLine(row)
+		if err != nil {
+			return err
+		}
+		if strings.Contains(line, substr) {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("text %q not found on row %d within timeout\nCurrent line content:\n%s", substr, row, line)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// WaitForLinePrefix waits until a line on the screen starts with prefix.
+// Unlike WaitFor, text appearing in the middle of a line does not match.
+func (e *Emulator) WaitForLinePrefix(prefix string, timeout time.Duration) error {
+	return e.waitForScreen(timeout, func() string {
+		return fmt.Sprintf("no line starting with %q", prefix)
+	}, func(screen string) bool {
+		for _, line := range strings.Split(screen, "\n") {
+			if strings.HasPrefix(line, prefix) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// WaitForLineSuffix waits until a line on the screen ends with suffix.
+// Lines are compared with trailing spaces trimmed.
+func (e *Emulator) WaitForLineSuffix(suffix string, timeout time.Duration) error {
+	return e.waitForScreen(timeout, func() string {
+		return fmt.Sprintf("no line ending with %q", suffix)
+	}, func(screen string) bool {
+		for _, line := range strings.Split(screen, "\n") {
+			if strings.HasSuffix(line, suffix) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// WaitForContentRows waits until at least n rows of the screen have content.
+// On timeout the error reports how many rows had content.
+func (e *Emulator) WaitForContentRows(n int, timeout time.Duration) error {
+	var count int
+	return e.waitForScreen(timeout, func() string {
+		return fmt.Sprintf("only %d of %d rows have content", count, n)
+	}, func(screen string) bool {
+		count = contentRowCount(screen)
+		return count >= n
+	})
+}
+
+// waitForScreen polls the screen until match returns true.
+// On timeout it returns an error starting with desc() and including the last screen.
+func (e *Emulator) waitForScreen(timeout time.Duration, desc func() string, match func(screen string) bool) error {
 	deadline := time.Now().Add(timeout)
 	var lastScreen string
 
 	for {
-		screen, err := e.GetScreenText()
+		screen, err := e.getScreenText()
 		if err != nil {
 			return fmt.Errorf("failed to get screen text: %w", err)
 		}
 
 		lastScreen = screen
-		if strings.Contains(screen, text) {
+		if match(screen) {
 			return nil
 		}
 
 		if time.Now().After(deadline) {
-			return fmt.Errorf("text %q not found within timeout\nCurrent screen content:\n%s", text, lastScreen)
+			return fmt.Errorf("%s within timeout\nCurrent screen content:\n%s", desc(), lastScreen)
 		}
 
 		time.Sleep(50 * time.Millisecond)
@@ -324,7 +1141,9 @@ func (e *Emulator) WaitFor(text string, timeout time.Duration) error {
 }
 
 // Resize changes the terminal size dynamically.
-// Both PTY and libvterm are resized to match the new dimensions.
+// Both PTY and libvterm are resized to match the new dimensions. Resizing the PTY
+// makes the kernel send SIGWINCH to the program's foreground process group, so
+// programs that only listen for the signal are notified as well.
 func (e *Emulator) Resize(rows, cols uint16) error {
 	if e.ptmx == nil {
 		return errors.New("emulator not started")
@@ -352,10 +1171,69 @@ func (e *Emulator) Resize(rows, cols uint16) error {
 
 	// Mark as activity to trigger any waiting operations
 	e.lastActivity = time.Now()
+	e.generation++
+
+	return nil
+}
 
+// Signal sends sig to the program, e.g. syscall.SIGINT or syscall.SIGTERM to test
+// signal handling separately from the Ctrl-C byte. Only the started process
+// receives it, not its children. Returns an error if the program was not started
+// or has already been waited for by Close.
+func (e *Emulator) Signal(sig os.Signal) error {
+	if e.cmd == nil || e.cmd.Process == nil {
+		return errors.New("emulator not started")
+	}
+	if err := e.cmd.Process.Signal(sig); err != nil {
+		return fmt.Errorf("send %v: %w", sig, err)
+	}
 	return nil
 }
 
+// ResizeSequence applies a series of resizes, waiting for the screen to
+// stabilize for 'settle' after each one. Each element of sizes is {rows, cols}.
+// Returns an error if any resize fails or the screen does not settle within the
+// default wait timeout (see WithDefaultWaitTimeout).
+func (e *Emulator) ResizeSequence(sizes [][2]uint16, settle time.Duration) error {
+	for i, size := range sizes {
+		if err := e.Resize(size[0], size[1]); err != nil {
+			return fmt.Errorf("resize step %d (%dx%d): %w", i, size[0], size[1], err)
+		}
+		if !e.WaitStable(settle, e.getWaitTimeout()) {
+			return fmt.Errorf("resize step %d (%dx%d): screen did not stabilize", i, size[0], size[1])
+		}
+	}
+	return nil
+}
+
+// ResizeGradually simulates dragging the window from one size to another by
+// resizing steps times, interval apart, through evenly interpolated sizes ending
+// at to. Unlike ResizeSequence it does not wait for the screen to settle between
+// steps, so the program sees rapid consecutive resizes. Each size is {rows, cols}.
+// Returns the first error encountered.
+func (e *Emulator) ResizeGradually(from, to [2]uint16, steps int, interval time.Duration) error {
+	if steps < 1 {
+		return fmt.Errorf("steps must be at least 1, got %d", steps)
+	}
+
+	for i := 1; i <= steps; i++ {
+		rows := interpolate(from[0], to[0], i, steps)
+		cols := interpolate(from[1], to[1], i, steps)
+		if err := e.Resize(rows, cols); err != nil {
+			return fmt.Errorf("resize step %d (%dx%d): %w", i, rows, cols, err)
+		}
+		if i < steps {
+			time.Sleep(interval)
+		}
+	}
+	return nil
+}
+
+// interpolate returns the value at step i of steps between from and to.
+func interpolate(from, to uint16, i, steps int) uint16 {
+	return uint16(int(from) + (int(to)-int(from))*i/steps)
+}
+
 // GetRawBytes returns the raw bytes collected from PTY.
 // Raw bytes collection must be enabled with EnableRawBytesCollection().
 // Returns a copy of the collected bytes.
@@ -373,16 +1251,48 @@ func (e *Emulator) GetRawBytes() []byte {
 	return result
 }
 
+// SentBytes returns the bytes written to the PTY: keys sent with KeyPress and
+// friends as well as automatic terminal responses (cursor position reports,
+// answerback). Compare it with GetRawBytes to see what the program echoed.
+// Sent bytes collection must be enabled with EnableSentBytesCollection().
+// Returns a copy of the collected bytes.
+func (e *Emulator) SentBytes() []byte {
+	e.sentMu.Lock()
+	defer e.sentMu.Unlock()
+
+	if !e.collectSentBytes {
+		return nil
+	}
+
+	result := make([]byte, len(e.sentBytes))
+	copy(result, e.sentBytes)
+	return result
+}
+
+// GetOutputWithEscapes returns the PTY output decoded as UTF-8 text with escape
+// sequences left intact, for protocol-level assertions on what the program emitted.
+// Invalid UTF-8 is replaced with U+FFFD. Raw bytes collection must be enabled
+// with EnableRawBytesCollection().
+func (e *Emulator) GetOutputWithEscapes() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.collectRawBytes {
+		return ""
+	}
+	return strings.ToValidUTF8(string(e.rawBytes), "\uFFFD")
+}
+
 // GetCursorPosition returns the current cursor position from libvterm's internal state.
 // Returns the 1-based row and column position.
 func (e *Emulator) GetCursorPosition() (row, col int, err error) {
-	if e.state == nil {
-		return 0, 0, errors.New("emulator not started")
-	}
-
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
+	if e.state == nil {
+		return 0, 0, e.stateErr()
+	}
+
 	// Get cursor position from libvterm state (0-based)
 	r, c := e.state.GetCursorPos()
 	
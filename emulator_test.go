@@ -4,8 +4,18 @@
 package vtermtest_test
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"reflect"
+	"regexp"
 	"strings"
+	"sync"
+	"syscall"
 	"testing"
 	"time"
 
@@ -356,3 +366,1295 @@ func bytesEqual(a, b []byte) bool {
 func contains(s, substr string) bool {
 	return strings.Contains(s, substr)
 }
+
+// TestFrameCapture tests that each stable frame is recorded
+func TestFrameCapture(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(6, 40).
+		Command("sh", "-c", "echo 'step 1'; read a; echo 'step 2'; read b").
+		Env("LANG=C.UTF-8", "TERM=xterm").
+		EnableFrameCapture()
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	if err := emu.KeyPressString("<WaitFor step 1><WaitStable><Enter><WaitFor step 2><WaitStable><WaitStable>"); err != nil {
+		t.Fatalf("failed to send DSL keys: %v", err)
+	}
+
+	frames := emu.GetFrames()
+	if len(frames) != 2 {
+		t.Fatalf("expected 2 frames, got %d: %q", len(frames), frames)
+	}
+	if !contains(frames[0], "step 1") || contains(frames[0], "step 2") {
+		t.Errorf("unexpected first frame:\n%s", frames[0])
+	}
+	if !contains(frames[1], "step 2") {
+		t.Errorf("unexpected second frame:\n%s", frames[1])
+	}
+}
+
+// TestCountFramesDuring tests that only distinct rendered frames are counted
+func TestCountFramesDuring(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(6, 40).
+		Command("sh", "-c", "echo ready; read a; printf 'one\\n'; sleep 0.05; printf 'two\\n'; sleep 0.05; printf '\\033[H'; sleep 5").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	if err := emu.WaitFor("ready", 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	emu.WaitStable(50*time.Millisecond, 5*time.Second)
+
+	// Only "one" and "two" change the screen text; the echoed newline and the cursor move do not
+	n, err := emu.CountFramesDuring(func() error {
+		return emu.KeyPress(keys.Enter)
+	}, 500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("CountFramesDuring failed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 frames, got %d", n)
+	}
+
+	n, err = emu.CountFramesDuring(func() error { return nil }, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("CountFramesDuring failed: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("expected no frames on an idle screen, got %d", n)
+	}
+}
+
+// TestCloseKillsProcessGroup tests that background children do not outlive Close
+func TestCloseKillsProcessGroup(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(6, 40).
+		Command("sh", "-c", "sleep 30 & echo \"child=$!\"; wait").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+
+	if err := emu.WaitForLinePrefix("child=", 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	line, _ := emu.GetLine(0)
+	var pid int
+	if _, err := fmt.Sscanf(line, "child=%d", &pid); err != nil {
+		t.Fatalf("failed to parse child pid from %q: %v", line, err)
+	}
+
+	if err := emu.Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+
+	// The orphaned child may linger briefly as a zombie until it is reaped
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if syscall.Kill(pid, 0) != nil {
+			return
+		}
+		if stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid)); err == nil && strings.Contains(string(stat), ") Z ") {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("child process %d is still running after Close", pid)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// TestNegativeRowIndex tests that negative rows count from the bottom
+func TestNegativeRowIndex(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(4, 40).
+		Command("sh", "-c", "printf 'top\\033[4;1Hstatus\\033[3;1Habove'; sleep 5").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	emu.AssertLineEqual(t, -1, "status")
+	emu.AssertLineEqual(t, -2, "above")
+	emu.AssertLineEqual(t, -4, "top")
+	emu.AssertLineEqual(t, 3, "status")
+
+	if _, err := emu.GetLine(-5); err == nil {
+		t.Error("expected an error for a row above the top of the screen")
+	}
+}
+
+// TestWithScreenLineEnding tests that rows are joined with the configured separator
+func TestWithScreenLineEnding(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(3, 20).
+		Command("sh", "-c", "printf 'one\\ntwo'; sleep 5").
+		Env("LANG=C.UTF-8", "TERM=xterm").
+		WithScreenLineEnding("\r\n")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	if err := emu.WaitFor("two", 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	screen, err := emu.GetScreenText()
+	if err != nil {
+		t.Fatalf("failed to get screen: %v", err)
+	}
+	if screen != "one\r\ntwo\r\n" {
+		t.Errorf("expected CRLF separated rows, got %q", screen)
+	}
+	emu.AssertScreenEqual(t, "one\r\ntwo")
+}
+
+// TestGetScreenTextRaw tests that trailing spaces are kept in the raw screen text
+func TestGetScreenTextRaw(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(3, 10).
+		Command("sh", "-c", "printf '[##  ]    \\nab'; sleep 5").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+
+	if _, err := emu.GetScreenTextRaw(); err == nil {
+		t.Error("expected an error before Start")
+	}
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	if err := emu.WaitFor("ab", 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	screen, err := emu.GetScreenTextRaw()
+	if err != nil {
+		t.Fatalf("failed to get screen: %v", err)
+	}
+	if want := "[##  ]    \nab        \n          "; screen != want {
+		t.Errorf("expected %q, got %q", want, screen)
+	}
+
+	trimmed, _ := emu.GetScreenText()
+	if trimmed != "[##  ]\nab\n" {
+		t.Errorf("expected trimmed screen, got %q", trimmed)
+	}
+}
+
+// TestGetScreenGrid tests that wide characters fill two grid cells
+func TestGetScreenGrid(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(2, 6).
+		Command("sh", "-c", "printf '日本a'; sleep 5").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	if err := emu.WaitFor("日本a", 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	grid, err := emu.GetScreenGrid()
+	if err != nil {
+		t.Fatalf("failed to get grid: %v", err)
+	}
+	want := [][]rune{
+		{'日', 0, '本', 0, 'a', ' '},
+		{' ', ' ', ' ', ' ', ' ', ' '},
+	}
+	if !reflect.DeepEqual(grid, want) {
+		t.Errorf("expected %q, got %q", want, grid)
+	}
+}
+
+// TestCombiningCharacters tests that combining marks and joined emoji are kept in lines
+func TestCombiningCharacters(t *testing.T) {
+	ctx := context.Background()
+
+	accent := "cafe\u0301"
+	family := "\U0001F468\u200D\U0001F469\u200D\U0001F467"
+
+	emu := vtermtest.New(3, 20).
+		Command("sh", "-c", "printf '%s\\n%s\\nend' \"$1\" \"$2\"; sleep 5", "sh", accent, family).
+		Env("LANG=C.UTF-8", "TERM=xterm")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	if err := emu.WaitFor("end", 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	emu.AssertLineEqual(t, 0, accent)
+	emu.AssertLineEqual(t, 1, family)
+}
+
+// TestSentBytes tests that input written to the PTY is collected
+func TestSentBytes(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(6, 40).
+		Command("sh", "-c", "stty raw -echo; printf 'ready'; cat > /dev/null").
+		Env("LANG=C.UTF-8", "TERM=xterm").
+		EnableSentBytesCollection()
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	if err := emu.WaitFor("ready", 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := emu.KeyPress(keys.Text("ab"), keys.Tab, keys.CtrlC); err != nil {
+		t.Fatalf("failed to send keys: %v", err)
+	}
+
+	if got := emu.SentBytes(); !bytes.Equal(got, []byte("ab\t\x03")) {
+		t.Errorf("unexpected sent bytes: %q", got)
+	}
+
+	if got := vtermtest.New(1, 1).SentBytes(); got != nil {
+		t.Errorf("expected nil when collection is disabled, got %q", got)
+	}
+}
+
+// TestWithKeyPressDelay tests that key sequences are spaced out by the delay
+func TestWithKeyPressDelay(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(6, 40).
+		Command("sh", "-c", "stty raw -echo; printf 'ready'; cat > /dev/null").
+		Env("LANG=C.UTF-8", "TERM=xterm").
+		EnableSentBytesCollection().
+		WithKeyPressDelay(50 * time.Millisecond)
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	if err := emu.WaitFor("ready", 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first sequence is sent immediately; the next three wait 50ms each
+	start := time.Now()
+	if err := emu.KeyPress(keys.Text("ab")); err != nil {
+		t.Fatalf("failed to send keys: %v", err)
+	}
+	if err := emu.KeyPressString("c<Tab>d"); err != nil {
+		t.Fatalf("failed to send DSL: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("expected at least 150ms for four key sequences, took %v", elapsed)
+	}
+
+	if got := emu.SentBytes(); !bytes.Equal(got, []byte("abc\td")) {
+		t.Errorf("unexpected sent bytes: %q", got)
+	}
+}
+
+// TestWriteRaw tests that raw bytes reach the program unchanged
+func TestWriteRaw(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(6, 40).
+		Command("sh", "-c", "stty raw -echo; printf 'ready\\r\\n'; head -c 4 | od -An -c").
+		Env("LANG=C.UTF-8", "TERM=xterm").
+		WithKeyOverride("Up", []byte("\x1b[1A"))
+
+	if _, err := emu.WriteRaw([]byte("x")); err == nil {
+		t.Error("expected an error before Start")
+	}
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	emu.AssertScreenContains(t, "ready")
+
+	// Overrides apply to KeyPress only, not to raw writes
+	n, err := emu.WriteRaw([]byte("\x1b[A<"))
+	if err != nil {
+		t.Fatalf("WriteRaw failed: %v", err)
+	}
+	if n != 4 {
+		t.Errorf("expected 4 bytes written, got %d", n)
+	}
+
+	emu.AssertScreenContains(t, "033   [   A   <")
+}
+
+// TestSignal tests that signals reach the program and that resizing sends SIGWINCH
+func TestSignal(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(6, 40).
+		Command("sh", "-c", "trap 'echo got INT' INT; trap 'echo got WINCH' WINCH; echo ready; while :; do sleep 0.1; done").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+
+	if err := emu.Signal(syscall.SIGINT); err == nil {
+		t.Error("expected an error before Start")
+	}
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+
+	emu.AssertScreenContains(t, "ready")
+
+	if err := emu.Signal(syscall.SIGINT); err != nil {
+		t.Fatalf("Signal failed: %v", err)
+	}
+	emu.AssertScreenContains(t, "got INT")
+
+	if err := emu.Resize(8, 40); err != nil {
+		t.Fatalf("Resize failed: %v", err)
+	}
+	emu.AssertScreenContains(t, "got WINCH")
+
+	_ = emu.Close()
+	if err := emu.Signal(syscall.SIGTERM); err == nil {
+		t.Error("expected an error after the program exited")
+	}
+}
+
+// TestWait tests that exit codes and termination by signal are reported
+func TestWait(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(6, 40).
+		Command("sh", "-c", "echo bad input; exit 3").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+	if _, err := emu.Wait(ctx); err == nil {
+		t.Error("expected an error before Start")
+	}
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	code, err := emu.Wait(ctx)
+	if err != nil || code != 3 {
+		t.Errorf("expected exit code 3, got %d (err: %v)", code, err)
+	}
+
+	// A program killed by a signal reports a SignalError
+	emu2 := vtermtest.New(6, 40).
+		Command("sh", "-c", "sleep 10").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+	if err := emu2.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu2.Close()
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if _, err := emu2.Wait(timeoutCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline error while running, got: %v", err)
+	}
+
+	if err := emu2.Signal(syscall.SIGTERM); err != nil {
+		t.Fatalf("Signal failed: %v", err)
+	}
+	code, err = emu2.Wait(ctx)
+	var sigErr *vtermtest.SignalError
+	if code != -1 || !errors.As(err, &sigErr) || sigErr.Signal != syscall.SIGTERM {
+		t.Errorf("expected SIGTERM, got %d (err: %v)", code, err)
+	}
+}
+
+// TestDone tests that the done channel closes when the program exits
+func TestDone(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(6, 40).
+		Command("sh", "-c", "read x; echo finished").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	select {
+	case <-emu.Done():
+		t.Fatal("done closed while the program was running")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if err := emu.KeyPress(keys.Enter); err != nil {
+		t.Fatalf("failed to send keys: %v", err)
+	}
+	select {
+	case <-emu.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("done not closed after the program exited")
+	}
+
+	// Output is fully rendered once done is closed
+	screen, err := emu.GetScreenText()
+	if err != nil || !strings.Contains(screen, "finished") {
+		t.Errorf("expected final output on screen, got %q (err: %v)", screen, err)
+	}
+}
+
+// TestGetScreenLinesIndexed tests that lines keep their screen rows
+func TestGetScreenLinesIndexed(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(5, 40).
+		Command("sh", "-c", "printf 'error: failed\\n\\n$ '; sleep 5").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	emu.AssertLineEqual(t, 2, "$")
+
+	lines, err := emu.GetScreenLinesIndexed(true)
+	if err != nil {
+		t.Fatalf("GetScreenLinesIndexed failed: %v", err)
+	}
+	want := []vtermtest.IndexedLine{{Row: 0, Text: "error: failed"}, {Row: 2, Text: "$"}}
+	if fmt.Sprint(lines) != fmt.Sprint(want) {
+		t.Errorf("expected %v, got %v", want, lines)
+	}
+
+	all, err := emu.GetScreenLinesIndexed(false)
+	if err != nil {
+		t.Fatalf("GetScreenLinesIndexed failed: %v", err)
+	}
+	if len(all) != 5 || all[4].Row != 4 || all[4].Text != "" {
+		t.Errorf("expected all 5 rows, got %v", all)
+	}
+}
+
+// TestWithUTF8 tests that multibyte output is decoded according to the UTF-8 mode
+func TestWithUTF8(t *testing.T) {
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		utf8 bool
+		want string
+	}{
+		{utf8: true, want: "café"},
+		{utf8: false, want: "cafÃ©"},
+	} {
+		emu := vtermtest.New(3, 20).
+			Command("printf", "caf\\303\\251").
+			Env("LANG=C.UTF-8", "TERM=xterm").
+			WithUTF8(tt.utf8)
+
+		if err := emu.Start(ctx); err != nil {
+			t.Fatalf("failed to start emulator: %v", err)
+		}
+		emu.AssertLineEqual(t, 0, tt.want)
+		emu.Close()
+	}
+}
+
+// TestKeyPressStringLabels tests that script failures name the last label
+func TestKeyPressStringLabels(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(6, 40).
+		Command("sh", "-c", "echo 'login:'; read a; echo 'menu'; sleep 5").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	err := emu.KeyPressString("<Label login><WaitFor login:>alice<Enter><Label open settings><WaitFor settings>")
+	if err == nil {
+		t.Fatal("expected the script to fail")
+	}
+	if !strings.HasPrefix(err.Error(), `step "open settings": `) {
+		t.Errorf("expected the error to name the failing step, got: %v", err)
+	}
+	emu.AssertScreenContains(t, "menu")
+}
+
+// TestWaitStableWithBlinkingCursor tests that cursor blinking does not affect stability
+func TestWaitStableWithBlinkingCursor(t *testing.T) {
+	ctx := context.Background()
+
+	// Toggle cursor visibility and blink mode continuously
+	emu := vtermtest.New(6, 40).
+		Command("sh", "-c", "printf 'Ready'; while true; do printf '\\033[?25l\\033[?12h'; sleep 0.02; printf '\\033[?25h\\033[?12l'; sleep 0.02; done").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	if err := emu.WaitFor("Ready", 2*time.Second); err != nil {
+		t.Fatalf("WaitFor failed: %v", err)
+	}
+
+	if !emu.WaitStable(200*time.Millisecond, 2*time.Second) {
+		t.Fatal("screen did not stabilize while the cursor was blinking")
+	}
+}
+
+// TestWaitStableWithRedraws tests that identical redraws do not prevent stability
+// while changing text does
+func TestWaitStableWithRedraws(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(6, 40).
+		Command("sh", "-c", "for i in 1 2 3 4 5 6 7 8 9 10; do printf '\\rcount %s' $i; sleep 0.05; done; while true; do printf '\\rdone    '; sleep 0.02; done").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	if err := emu.WaitFor("count 1", 2*time.Second); err != nil {
+		t.Fatalf("WaitFor failed: %v", err)
+	}
+
+	// The counter changes every 50ms, so 200ms of quiet is reached only after it
+	if !emu.WaitStable(200*time.Millisecond, 3*time.Second) {
+		t.Fatal("screen did not stabilize while redrawing identical text")
+	}
+	if line, _ := emu.GetLine(0); line != "done" {
+		t.Errorf("expected stability only after the counter, got %q", line)
+	}
+}
+
+// TestFinalScreen tests that the last frame of a short-lived command is captured
+func TestFinalScreen(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(6, 40).
+		Command("sh", "-c", "printf 'first\\nlast line'").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+
+	// No sleeps: FinalScreen waits for the output to end
+	screen, err := emu.FinalScreen()
+	if err != nil {
+		t.Fatalf("FinalScreen failed: %v", err)
+	}
+	if !contains(screen, "last line") {
+		t.Errorf("expected final frame to contain 'last line', got:\n%s", screen)
+	}
+
+	emu.Close()
+
+	// Still available after Close
+	afterClose, err := emu.FinalScreen()
+	if err != nil {
+		t.Fatalf("FinalScreen after Close failed: %v", err)
+	}
+	if afterClose != screen {
+		t.Errorf("final screen changed after Close:\nbefore:\n%s\nafter:\n%s", screen, afterClose)
+	}
+	if text, err := emu.GetScreenText(); err != nil || text != screen {
+		t.Errorf("GetScreenText after Close = %q, %v; want the final screen", text, err)
+	}
+
+	// Cursor accessors must not touch the freed libvterm state
+	if _, _, err := emu.GetCursorPosition(); err == nil || err.Error() != "emulator closed" {
+		t.Errorf("GetCursorPosition after Close: got error %v, want \"emulator closed\"", err)
+	}
+	if _, err := emu.GetCursorCell(); err == nil || err.Error() != "emulator closed" {
+		t.Errorf("GetCursorCell after Close: got error %v, want \"emulator closed\"", err)
+	}
+}
+
+// TestScreenTextCacheInvalidation tests that cached screen text is refreshed after new output
+func TestScreenTextCacheInvalidation(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(6, 40).
+		Command("sh", "-c", "echo 'before'; read x; echo 'after'; sleep 1").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	if err := emu.WaitFor("before", 2*time.Second); err != nil {
+		t.Fatalf("WaitFor failed: %v", err)
+	}
+
+	first, _ := emu.GetScreenText()
+	second, _ := emu.GetScreenText()
+	if first != second {
+		t.Errorf("repeated reads without output differ:\n%s\n---\n%s", first, second)
+	}
+
+	if err := emu.KeyPress(keys.Enter); err != nil {
+		t.Fatalf("failed to send keys: %v", err)
+	}
+	if err := emu.WaitFor("after", 2*time.Second); err != nil {
+		t.Fatalf("cached screen was not refreshed: %v", err)
+	}
+}
+
+// TestSendRunes tests that runes are delivered individually
+func TestSendRunes(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(6, 40).
+		Command("sh", "-c", "read input; echo \"Got: $input\"").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	if err := emu.SendRunes([]rune("日本語\r"), 10*time.Millisecond); err != nil {
+		t.Fatalf("SendRunes failed: %v", err)
+	}
+
+	emu.AssertScreenContains(t, "Got: 日本語")
+}
+
+// TestLineLastChanged tests per-row change timestamps
+func TestLineLastChanged(t *testing.T) {
+	ctx := context.Background()
+
+	// Row 0 is drawn once, row 1 keeps updating
+	emu := vtermtest.New(6, 40).
+		Command("sh", "-c", "printf 'frozen panel\\n'; i=0; while true; do i=$((i+1)); printf '\\rtick %d' $i; sleep 0.05; done").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	if err := emu.WaitFor("tick", 2*time.Second); err != nil {
+		t.Fatalf("WaitFor failed: %v", err)
+	}
+	time.Sleep(300 * time.Millisecond)
+
+	frozen := emu.LineLastChanged(0)
+	ticking := emu.LineLastChanged(1)
+	if frozen.IsZero() || ticking.IsZero() {
+		t.Fatalf("expected both rows to have change times, got %v and %v", frozen, ticking)
+	}
+	if time.Since(ticking) > time.Second {
+		t.Errorf("ticking row should have changed recently, last change %v ago", time.Since(ticking))
+	}
+	if !frozen.Before(ticking) {
+		t.Errorf("frozen row changed after ticking row: %v >= %v", frozen, ticking)
+	}
+	if !emu.LineLastChanged(5).IsZero() {
+		t.Errorf("untouched row should have zero change time")
+	}
+}
+
+// TestWaitForLineAnchors tests prefix/suffix anchored waits
+func TestWaitForLineAnchors(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(6, 40).
+		Command("sh", "-c", "echo 'status: Ready soon'; sleep 0.3; echo 'Ready: yes'; sleep 2").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	// "Ready" appears mid-line first; only the second line starts with it
+	if err := emu.WaitForLinePrefix("Ready", 2*time.Second); err != nil {
+		t.Fatalf("WaitForLinePrefix failed: %v", err)
+	}
+	if err := emu.WaitForLineSuffix("yes", 2*time.Second); err != nil {
+		t.Fatalf("WaitForLineSuffix failed: %v", err)
+	}
+
+	err := emu.WaitForLinePrefix("soon", 200*time.Millisecond)
+	if err == nil {
+		t.Fatal("expected WaitForLinePrefix to time out for mid-line text")
+	}
+	if !strings.Contains(err.Error(), "Current screen content:") {
+		t.Errorf("error should include the screen content, got: %v", err)
+	}
+}
+
+// TestMeasureResponse tests latency measurement between keys and output
+func TestMeasureResponse(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(6, 40).
+		Command("sh", "-c", "stty -echo; read x; sleep 0.2; echo 'done'; sleep 2").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	time.Sleep(200 * time.Millisecond)
+
+	latency, err := emu.MeasureResponse(keys.Enter)
+	if err != nil {
+		t.Fatalf("MeasureResponse failed: %v", err)
+	}
+	if latency < 200*time.Millisecond || latency > 2*time.Second {
+		t.Errorf("unexpected latency: %v", latency)
+	}
+	t.Logf("latency: %v", latency)
+}
+
+// TestTraceWriter tests that PTY traffic is logged in both directions
+func TestTraceWriter(t *testing.T) {
+	ctx := context.Background()
+
+	var trace safeBuffer
+	emu := vtermtest.New(6, 40).
+		Command("sh", "-c", "read x; echo \"got $x\"; sleep 1").
+		Env("LANG=C.UTF-8", "TERM=xterm").
+		WithTraceWriter(&trace)
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	if err := emu.KeyPress(keys.Text("ping"), keys.Enter); err != nil {
+		t.Fatalf("failed to send keys: %v", err)
+	}
+	if err := emu.WaitFor("got ping", 2*time.Second); err != nil {
+		t.Fatalf("WaitFor failed: %v", err)
+	}
+
+	log := trace.String()
+	if !strings.Contains(log, ">> 4 bytes") {
+		t.Errorf("trace should contain the written keys:\n%s", log)
+	}
+	if !strings.Contains(log, "<< ") {
+		t.Errorf("trace should contain the program output:\n%s", log)
+	}
+}
+
+// safeBuffer is a bytes.Buffer safe for concurrent use
+type safeBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *safeBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *safeBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// TestWaitForContentRows tests waiting for a number of non-empty rows
+func TestWaitForContentRows(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(10, 40).
+		Command("sh", "-c", "for i in 1 2 3 4 5; do echo \"log $i\"; sleep 0.05; done; sleep 2").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	if err := emu.WaitForContentRows(5, 2*time.Second); err != nil {
+		t.Fatalf("WaitForContentRows failed: %v", err)
+	}
+
+	count, err := emu.ContentRowCount()
+	if err != nil {
+		t.Fatalf("ContentRowCount failed: %v", err)
+	}
+	if count != 5 {
+		t.Errorf("expected 5 content rows, got %d", count)
+	}
+
+	err = emu.WaitForContentRows(8, 200*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "only 5 of 8 rows") {
+		t.Errorf("expected timeout reporting 5 of 8 rows, got: %v", err)
+	}
+}
+
+// TestGetOutputWithEscapes tests the decoded output stream keeps escape sequences
+func TestGetOutputWithEscapes(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(6, 40).
+		Command("printf", "\\033[1mbold\\033[0m 日本").
+		Env("LANG=C.UTF-8", "TERM=xterm").
+		EnableRawBytesCollection()
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	emu.AssertScreenContains(t, "bold 日本")
+
+	out := emu.GetOutputWithEscapes()
+	if !strings.Contains(out, "\x1b[1mbold\x1b[0m 日本") {
+		t.Errorf("expected escapes intact in output, got %q", out)
+	}
+}
+
+// TestDrain tests waiting for a previous phase's output to go quiet
+func TestDrain(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(6, 40).
+		Command("sh", "-c", "for i in 1 2 3 4 5; do echo \"phase1 $i\"; sleep 0.05; done; read x; echo 'phase2'; sleep 2").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	if err := emu.Drain(150 * time.Millisecond); err != nil {
+		t.Fatalf("Drain failed: %v", err)
+	}
+	emu.AssertScreenContains(t, "phase1 5")
+
+	latency, err := emu.MeasureResponse(keys.Enter)
+	if err != nil {
+		t.Fatalf("MeasureResponse after Drain failed: %v", err)
+	}
+	if latency > time.Second {
+		t.Errorf("unexpected latency after Drain: %v", latency)
+	}
+	emu.AssertScreenContains(t, "phase2")
+}
+
+// TestReset tests hard and soft resets of a running emulator
+func TestReset(t *testing.T) {
+	ctx := context.Background()
+
+	for _, hard := range []bool{true, false} {
+		t.Run(fmt.Sprintf("hard=%v", hard), func(t *testing.T) {
+			emu := vtermtest.New(6, 40).
+				Command("sh", "-c", "printf '\\033[?1hcontent'; sleep 2").
+				Env("LANG=C.UTF-8", "TERM=xterm").
+				WithInitialReset(hard)
+
+			if err := emu.Start(ctx); err != nil {
+				t.Fatalf("failed to start emulator: %v", err)
+			}
+			defer emu.Close()
+
+			emu.AssertScreenContains(t, "content")
+
+			if err := emu.Reset(); err != nil {
+				t.Fatalf("Reset failed: %v", err)
+			}
+			if emu.ApplicationCursorKeys() {
+				t.Error("modes should be cleared by any reset")
+			}
+
+			screen, _ := emu.GetScreenText()
+			if hard && contains(screen, "content") {
+				t.Errorf("hard reset should clear the screen, got:\n%s", screen)
+			}
+			if !hard && !contains(screen, "content") {
+				t.Errorf("soft reset should keep the screen, got:\n%s", screen)
+			}
+		})
+	}
+}
+
+// TestInputChan tests driving input from another goroutine
+func TestInputChan(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(6, 40).
+		Command("sh", "-c", "read a; echo \"first: $a\"; read b; echo \"second: $b\"").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	in := emu.InputChan()
+	if emu.InputChan() != in {
+		t.Fatal("InputChan returned a different channel on the second call")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, s := range []string{"o", "n", "e", "\r"} {
+			in <- []byte(s)
+		}
+		if err := emu.WaitFor("first: one", 5*time.Second); err != nil {
+			return
+		}
+		in <- []byte("two\r")
+	}()
+
+	emu.AssertScreenContains(t, "first: one")
+	emu.AssertScreenContains(t, "second: two")
+	<-done
+}
+
+// TestStdin tests streaming input through the io.Writer adapter
+func TestStdin(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(6, 40).
+		Command("sh", "-c", "stty -echo; while read line; do echo \"> $line\"; done").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	if _, err := io.Copy(emu.Stdin(), strings.NewReader("one\ntwo\n")); err != nil {
+		t.Fatalf("io.Copy failed: %v", err)
+	}
+	fmt.Fprintf(emu.Stdin(), "%s-%d\n", "three", 3)
+
+	emu.AssertScreenEqual(t, "> one\n> two\n> three-3")
+}
+
+// TestOnScreenChange tests that the callback sees each change to the screen text
+func TestOnScreenChange(t *testing.T) {
+	ctx := context.Background()
+
+	screens := make(chan string, 10)
+	var emu *vtermtest.Emulator
+	emu = vtermtest.New(3, 20).
+		Command("sh", "-c", "stty -echo; printf 'one'; read x; printf '\\r\\033[1mone\\033[0m'; printf '\\rtwo'; sleep 5").
+		Env("LANG=C.UTF-8", "TERM=xterm").
+		OnScreenChange(func(screen string) {
+			// Calling back into the emulator must not deadlock
+			if _, err := emu.GetScreenText(); err != nil {
+				t.Errorf("GetScreenText failed in callback: %v", err)
+			}
+			screens <- screen
+		})
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	next := func() string {
+		t.Helper()
+		select {
+		case s := <-screens:
+			return s
+		case <-time.After(5 * time.Second):
+			t.Fatal("no screen change reported")
+			return ""
+		}
+	}
+
+	if got := next(); got != "one\n\n" {
+		t.Errorf("expected first screen, got %q", got)
+	}
+
+	// Redrawing "one" in bold leaves the text unchanged and is not reported
+	if err := emu.KeyPress(keys.Enter); err != nil {
+		t.Fatalf("failed to send keys: %v", err)
+	}
+	if got := next(); got != "two\n\n" {
+		t.Errorf("expected second screen, got %q", got)
+	}
+}
+
+// TestCaptureProgression tests recording a frame per DSL token
+func TestCaptureProgression(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(4, 20).
+		Command("sh", "-c", "stty -echo; while read line; do echo \"> $line\"; done").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	frames, err := emu.CaptureProgression("<Label first>a<Enter><WaitFor > a>b<Enter>", 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("CaptureProgression failed: %v", err)
+	}
+
+	want := []vtermtest.Frame{
+		{Token: `"a"`, Screen: ""},
+		{Token: `"\r"`, Screen: "> a"},
+		{Token: `"b"`, Screen: "> a"},
+		{Token: `"\r"`, Screen: "> a\n> b"},
+	}
+	if len(frames) != len(want) {
+		t.Fatalf("got %d frames, want %d: %+v", len(frames), len(want), frames)
+	}
+	for i, f := range frames {
+		if f.Token != want[i].Token || strings.TrimRight(f.Screen, "\n") != want[i].Screen {
+			t.Errorf("frame %d: got {%s %q}, want {%s %q}", i, f.Token, f.Screen, want[i].Token, want[i].Screen)
+		}
+	}
+}
+
+// TestWaitForRegexp tests waiting on a dynamic prompt
+func TestWaitForRegexp(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(4, 40).
+		Command("sh", "-c", "sleep 0.1; printf 'alice@devbox:~$ '; sleep 5").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	match, err := emu.WaitForRegexp(regexp.MustCompile(`(?m)^(\w+)@(\w+):~\$$`), 5*time.Second)
+	if err != nil {
+		t.Fatalf("WaitForRegexp failed: %v", err)
+	}
+	if len(match) != 3 || match[1] != "alice" || match[2] != "devbox" {
+		t.Errorf("unexpected submatches: %q", match)
+	}
+
+	if _, err := emu.WaitForRegexp(regexp.MustCompile(`root@`), 100*time.Millisecond); err == nil {
+		t.Error("expected a timeout error")
+	}
+}
+
+// TestWaitForLine tests waiting for text on one row only
+func TestWaitForLine(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(4, 40).
+		Command("sh", "-c", "echo 'saved'; sleep 0.2; printf '\\033[4;1Hstatus: saved'; sleep 5").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	if err := emu.WaitForLine(-1, "saved", 5*time.Second); err != nil {
+		t.Fatalf("WaitForLine failed: %v", err)
+	}
+	if line, _ := emu.GetLine(3); line != "status: saved" {
+		t.Errorf("row 3 = %q, want %q", line, "status: saved")
+	}
+
+	start := time.Now()
+	if err := emu.WaitForLine(4, "saved", 5*time.Second); err == nil || !strings.Contains(err.Error(), "out of range") {
+		t.Errorf("expected an out of range error, got: %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("out of range row was not reported immediately")
+	}
+}
+
+// TestDefaultWaitSettings tests that DSL waits honor the configured defaults
+func TestDefaultWaitSettings(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(4, 40).
+		Command("sh", "-c", "echo ready; sleep 5").
+		Env("LANG=C.UTF-8", "TERM=xterm").
+		WithDefaultStableQuiet(20 * time.Millisecond).
+		WithDefaultWaitTimeout(200 * time.Millisecond)
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	if err := emu.KeyPressString("<WaitFor ready><WaitStable>"); err != nil {
+		t.Fatalf("KeyPressString failed: %v", err)
+	}
+
+	start := time.Now()
+	if err := emu.KeyPressString("<WaitFor never shown>"); err == nil {
+		t.Fatal("expected <WaitFor> to time out")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("<WaitFor> took %v, want about 200ms", elapsed)
+	}
+}
+
+// TestKeyPressStringSleep tests the <Sleep> DSL tag
+func TestKeyPressStringSleep(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(4, 40).
+		Command("sh", "-c", "read a; echo \"got $a\"; sleep 5").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	start := time.Now()
+	if err := emu.KeyPressString("a<Sleep 150ms>b<Enter>"); err != nil {
+		t.Fatalf("KeyPressString failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("KeyPressString returned after %v, want at least 150ms", elapsed)
+	}
+	emu.AssertScreenContains(t, "got ab")
+
+	if err := emu.KeyPressString("<Sleep forever>"); err == nil || !strings.Contains(err.Error(), "invalid sleep duration") {
+		t.Errorf("expected an invalid duration error, got: %v", err)
+	}
+}
+
+// TestKeyPressStringMouse tests that mouse tags are written through the PTY
+func TestKeyPressStringMouse(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(4, 40).
+		Command("sh", "-c", "printf '\\033[?1000h\\033[?1006hready'; sleep 5").
+		Env("LANG=C.UTF-8", "TERM=xterm").
+		EnableSentBytesCollection()
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start emulator: %v", err)
+	}
+	defer emu.Close()
+
+	if err := emu.WaitFor("ready", 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if !emu.ModeEnabled(vtermtest.ModeMouseTracking) || !emu.ModeEnabled(vtermtest.ModeSGRMouse) {
+		t.Fatal("expected SGR mouse reporting to be enabled")
+	}
+	if err := emu.KeyPressString("<Mouse 1,2 Left>"); err != nil {
+		t.Fatalf("KeyPressString failed: %v", err)
+	}
+
+	if got, want := string(emu.SentBytes()), "\x1b[<0;3;2M\x1b[<0;3;2m"; got != want {
+		t.Errorf("sent %q, want %q", got, want)
+	}
+}
+
+func TestAnswerback(t *testing.T) {
+	emu := vtermtest.New(6, 40).
+		Command("sh", "-c", "stty raw -echo; printf '\\005'; head -c 5 | od -An -c").
+		WithAnswerback("VT100")
+	t.Cleanup(func() { _ = emu.Close() })
+
+	if err := emu.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	emu.AssertScreenContains(t, "V   T   1   0   0")
+}
+
+func TestKeyOverride(t *testing.T) {
+	emu := vtermtest.New(6, 60).
+		Command("sh", "-c", "stty raw -echo; printf 'ready\\r\\n'; head -c 11 | od -An -c").
+		WithKeyOverride("Home", []byte("\x1b[1~"))
+	t.Cleanup(func() { _ = emu.Close() })
+
+	if err := emu.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	emu.AssertScreenContains(t, "ready")
+
+	// Both the keys.Home value and the DSL tag are remapped
+	if err := emu.KeyPress(keys.Home); err != nil {
+		t.Fatalf("send home: %v", err)
+	}
+	if err := emu.KeyPressString("<Home>"); err != nil {
+		t.Fatalf("send DSL home: %v", err)
+	}
+	// Text with the same bytes is not a named key
+	if err := emu.KeyPress(keys.Text("\x1b[H")); err != nil {
+		t.Fatalf("send text: %v", err)
+	}
+
+	emu.AssertScreenContains(t, "033   [   1   ~ 033   [   1   ~ 033   [   H")
+}
+
+func TestKeyOverrideSwap(t *testing.T) {
+	emu := vtermtest.New(6, 40).
+		Command("sh", "-c", "stty raw -echo; printf 'ready\\r\\n'; head -c 6 | od -An -c").
+		WithKeyOverride("Home", keys.End).
+		WithKeyOverride("End", keys.Home)
+	t.Cleanup(func() { _ = emu.Close() })
+
+	if err := emu.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	emu.AssertScreenContains(t, "ready")
+
+	// Each override is applied once, so swapped keys are not swapped back
+	if err := emu.KeyPressString("<Home>"); err != nil {
+		t.Fatalf("send DSL home: %v", err)
+	}
+	if err := emu.KeyPress(keys.End); err != nil {
+		t.Fatalf("send end: %v", err)
+	}
+
+	emu.AssertScreenContains(t, "033   [   F 033   [   H")
+}
+
+func TestWithCmdRejectsSetpgid(t *testing.T) {
+	cmd := exec.Command("true")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	emu := vtermtest.New(5, 20).WithCmd(cmd)
+	err := emu.Start(context.Background())
+	if err == nil {
+		emu.Close()
+		t.Fatal("expected Start to reject SysProcAttr.Setpgid")
+	}
+	if !strings.Contains(err.Error(), "Setpgid") {
+		t.Errorf("error does not name the conflicting setting: %v", err)
+	}
+}
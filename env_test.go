@@ -2,6 +2,7 @@ package vtermtest_test
 
 import (
 	"context"
+	"os/exec"
 	"strings"
 	"testing"
 	"time"
@@ -90,4 +91,26 @@ func TestCtrlKeys(t *testing.T) {
 	if !strings.Contains(screen, "test") {
 		t.Errorf("Expected 'test' in output, got: %s", screen)
 	}
-}
\ No newline at end of file
+}
+
+func TestWithCmd(t *testing.T) {
+	ctx := context.Background()
+
+	cmd := exec.Command("sh", "-c", "echo $CUSTOM_VAR; pwd")
+	cmd.Env = []string{"CUSTOM_VAR=from_cmd", "LANG=C.UTF-8"}
+	cmd.Dir = "/tmp"
+
+	// Command/Env/Dir are ignored when a custom cmd is supplied
+	emu := vtermtest.New(10, 80).
+		Command("false").
+		Env("CUSTOM_VAR=from_env").
+		WithCmd(cmd)
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	emu.AssertScreenContains(t, "from_cmd")
+	emu.AssertScreenContains(t, "/tmp")
+}
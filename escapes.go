@@ -0,0 +1,67 @@
+package vtermtest
+
+import "bytes"
+
+// AssertNoEscapes asserts that the program's output so far contains no CSI
+// (ESC [) or OSC (ESC ]) sequences, reporting the first one found. Use it to
+// check that colors and cursor control are disabled, e.g. with TERM=dumb or
+// NO_COLOR set. Raw bytes collection must be enabled with EnableRawBytesCollection().
+//
+// The command still runs under a PTY, so programs that disable escapes only when
+// stdout is not a terminal are expected to emit them here.
+func (e *Emulator) AssertNoEscapes(t TestingT) {
+	t.Helper()
+
+	e.mu.Lock()
+	collecting := e.collectRawBytes
+	e.mu.Unlock()
+	if !collecting {
+		t.Fatalf("raw bytes collection is not enabled")
+		return
+	}
+
+	if offset, seq := firstEscape(e.GetRawBytes()); seq != nil {
+		t.Fatalf("unexpected escape sequence at byte %d: %q", offset, seq)
+	}
+}
+
+// maxEscapeReport bounds how much of an unterminated sequence is reported.
+const maxEscapeReport = 32
+
+// firstEscape returns the offset and bytes of the first CSI or OSC sequence in
+// data, or a nil sequence if there is none. A CSI ends at its final byte
+// (0x40-0x7E); an OSC ends at BEL or ST (ESC \). Unterminated sequences are
+// reported up to the end of data.
+func firstEscape(data []byte) (int, []byte) {
+	for i := 0; i+1 < len(data); i++ {
+		if data[i] != 0x1B {
+			continue
+		}
+
+		end := len(data)
+		switch data[i+1] {
+		case '[':
+			for j := i + 2; j < len(data); j++ {
+				if data[j] >= 0x40 && data[j] <= 0x7E {
+					end = j + 1
+					break
+				}
+			}
+		case ']':
+			if j := bytes.IndexAny(data[i+2:], "\a\x1b"); j >= 0 {
+				end = i + 2 + j + 1
+				if data[end-1] == 0x1B && end < len(data) && data[end] == '\\' {
+					end++
+				}
+			}
+		default:
+			continue
+		}
+
+		if end-i > maxEscapeReport {
+			end = i + maxEscapeReport
+		}
+		return i, data[i:end]
+	}
+	return 0, nil
+}
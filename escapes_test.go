@@ -0,0 +1,63 @@
+package vtermtest
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestFirstEscape(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		offset int
+		seq    string
+	}{
+		{name: "plain text", data: "hello\r\nworld", seq: ""},
+		{name: "color", data: "ok \x1b[31mred\x1b[0m", offset: 3, seq: "\x1b[31m"},
+		{name: "private mode", data: "\x1b[?25l", offset: 0, seq: "\x1b[?25l"},
+		{name: "osc with bel", data: "a\x1b]0;title\a", offset: 1, seq: "\x1b]0;title\a"},
+		{name: "osc with st", data: "\x1b]8;;url\x1b\\link", offset: 0, seq: "\x1b]8;;url\x1b\\"},
+		{name: "other escapes ignored", data: "\x1b7\x1bM\x1b", seq: ""},
+		{name: "unterminated", data: "x\x1b[12", offset: 1, seq: "\x1b[12"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offset, seq := firstEscape([]byte(tt.data))
+			if string(seq) != tt.seq || (seq != nil && offset != tt.offset) {
+				t.Errorf("firstEscape(%q) = %d, %q; want %d, %q", tt.data, offset, seq, tt.offset, tt.seq)
+			}
+		})
+	}
+}
+
+func TestAssertNoEscapes(t *testing.T) {
+	emu := New(4, 40).
+		Command("sh", "-c", "printf 'plain\\n'; [ -n \"$NO_COLOR\" ] || printf '\\033[1mbold\\033[0m\\n'; sleep 5").
+		Env("NO_COLOR=1").
+		EnableRawBytesCollection()
+	t.Cleanup(func() { _ = emu.Close() })
+
+	if err := emu.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	emu.AssertLineEqual(t, 0, "plain")
+	emu.AssertNoEscapes(t)
+
+	colored := New(4, 40).
+		Command("sh", "-c", "printf 'plain\\n'; [ -n \"$NO_COLOR\" ] || printf '\\033[1mbold\\033[0m\\n'; sleep 5").
+		EnableRawBytesCollection()
+	t.Cleanup(func() { _ = colored.Close() })
+
+	if err := colored.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	colored.AssertLineEqual(t, 1, "bold")
+
+	b := NewBatchAssert(t)
+	colored.AssertNoEscapes(b)
+	if failures := b.Failures(); len(failures) != 1 || !strings.Contains(failures[0], `"\x1b[1m"`) {
+		t.Errorf("expected the bold sequence to be reported, got %q", failures)
+	}
+}
@@ -6,6 +6,7 @@ require (
 	github.com/creack/pty v1.1.24
 	github.com/mattn/go-libvterm v0.0.0-20220218002314-74b0d3133396
 	github.com/mattn/go-runewidth v0.0.16
+	golang.org/x/text v0.14.0
 )
 
 require (
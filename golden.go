@@ -0,0 +1,76 @@
+package vtermtest
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// updateGolden reports whether golden files should be rewritten: when
+// VTERMTEST_GOLDEN_UPDATE=1 is set, or when the test binary defines an -update flag
+// that is set (vtermtest does not define the flag itself, to avoid clashing
+// with one the caller's tests already define).
+func updateGolden() bool {
+	if os.Getenv("VTERMTEST_GOLDEN_UPDATE") == "1" {
+		return true
+	}
+	if f := flag.Lookup("update"); f != nil {
+		return f.Value.String() == "true"
+	}
+	return false
+}
+
+// AssertScreenMatchesGolden asserts that the screen matches the contents of the
+// golden file at path. Trailing empty rows are ignored, and Unicode normalization
+// applies as in the other assertions. On mismatch a row-by-row diff is reported.
+//
+// When VTERMTEST_GOLDEN_UPDATE=1 is set, or the test binary's -update flag is set, the
+// file is instead (re)written with the current screen once it is stable,
+// creating parent directories as needed.
+func (e *Emulator) AssertScreenMatchesGolden(t TestingT, path string) {
+	t.Helper()
+
+	if updateGolden() {
+		e.WaitStable(e.getStableQuiet(), e.getWaitTimeout())
+		got, err := e.GetScreenText()
+		if err != nil {
+			t.Fatalf("failed to get screen: %v", err)
+			return
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create golden file directory: %v", err)
+			return
+		}
+		if err := os.WriteFile(path, []byte(e.normalize(strings.TrimRight(got, "\n"))+"\n"), 0o644); err != nil {
+			t.Fatalf("failed to write golden file: %v", err)
+		}
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("golden file %s does not exist; run with VTERMTEST_GOLDEN_UPDATE=1 to create it", path)
+		return
+	}
+	if err != nil {
+		t.Fatalf("failed to read golden file: %v", err)
+		return
+	}
+	want := e.normalize(strings.TrimRight(string(data), "\n"))
+
+	e.assertWithRetry(t, func() error {
+		got, err := e.GetScreenText()
+		if err != nil {
+			return fmt.Errorf("failed to get screen: %v", err)
+		}
+		got = e.normalize(strings.TrimRight(got, "\n"))
+
+		if got != want {
+			return fmt.Errorf("screen does not match golden file %s:\n%s", path, lineDiff(want, got))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,56 @@
+package vtermtest_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/c-bata/vtermtest"
+)
+
+func TestAssertScreenMatchesGolden(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(4, 20).
+		Command("sh", "-c", "printf 'hello\\nworld'; sleep 5").
+		Env("LANG=C.UTF-8").
+		WithAssertMaxAttempts(2)
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	emu.AssertLineEqual(t, 1, "world")
+	path := filepath.Join(t.TempDir(), "testdata", "screen.golden")
+
+	mt := &mockTest{}
+	emu.AssertScreenMatchesGolden(mt, path)
+	if !mt.failed || !strings.Contains(mt.message, "VTERMTEST_GOLDEN_UPDATE=1") {
+		t.Errorf("expected a missing golden file failure, got: %q", mt.message)
+	}
+
+	t.Setenv("VTERMTEST_GOLDEN_UPDATE", "1")
+	emu.AssertScreenMatchesGolden(t, path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("golden file not written: %v", err)
+	}
+	if string(data) != "hello\nworld\n" {
+		t.Errorf("golden file = %q, want %q", data, "hello\nworld\n")
+	}
+
+	t.Setenv("VTERMTEST_GOLDEN_UPDATE", "")
+	emu.AssertScreenMatchesGolden(t, path)
+
+	if err := os.WriteFile(path, []byte("hello\nthere\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mt = &mockTest{}
+	emu.AssertScreenMatchesGolden(mt, path)
+	if !mt.failed || !strings.Contains(mt.message, " hello\n-there\n+world") {
+		t.Errorf("expected a row diff, got: %q", mt.message)
+	}
+}
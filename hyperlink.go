@@ -0,0 +1,160 @@
+package vtermtest
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Hyperlink is an OSC 8 hyperlink on a screen row.
+type Hyperlink struct {
+	URL string
+	// ID is the link's id parameter (ESC ] 8 ; id=x ; url ST), or "" if none.
+	ID string
+	// StartCol and EndCol delimit the columns the link covers, 0-based and
+	// end-exclusive.
+	StartCol, EndCol int
+}
+
+// linkSpan is the part of a hyperlink on one row. Rows are counted from the
+// start of output (screen row plus scrolledLines) so spans follow scrolling,
+// and text is kept so spans whose cells were overwritten can be dropped.
+type linkSpan struct {
+	link       Hyperlink
+	absRow     int
+	text       string
+	start, end int
+}
+
+// openLink is the hyperlink currently being written.
+type openLink struct {
+	url, id     string
+	absRow, col int
+}
+
+// writeVTWithLinks writes output to libvterm, splitting it at OSC 8 sequences so
+// the cursor position where each hyperlink starts and ends can be recorded.
+// The caller must hold e.mu.
+func (e *Emulator) writeVTWithLinks(data []byte, cmds []oscCommand) error {
+	prev := 0
+	for _, cmd := range cmds {
+		if cmd.code != oscHyperlink || cmd.end <= prev || cmd.end > len(data) {
+			continue
+		}
+		if err := e.writeVT(data[prev:cmd.end]); err != nil {
+			return err
+		}
+		prev = cmd.end
+		e.hyperlinkBoundary(cmd.text)
+	}
+	return e.writeVT(data[prev:])
+}
+
+// hyperlinkBoundary ends the open hyperlink, if any, at the cursor and starts a
+// new one if text ("params;url") has a URL. The caller must hold e.mu.
+func (e *Emulator) hyperlinkBoundary(text string) {
+	row, col := e.state.GetCursorPos()
+	absRow := row + e.scrolledLines
+
+	if l := e.openLink; l != nil {
+		e.openLink = nil
+		for r := l.absRow; r <= absRow; r++ {
+			start, end := 0, int(e.cols)
+			if r == l.absRow {
+				start = l.col
+			}
+			if r == absRow {
+				end = col
+			}
+			screenRow := r - e.scrolledLines
+			if screenRow < 0 || end <= start {
+				continue
+			}
+			e.linkSpans = append(e.linkSpans, linkSpan{
+				link:   Hyperlink{URL: l.url, ID: l.id, StartCol: start, EndCol: end},
+				absRow: r,
+				text:   e.spanText(screenRow, start, end),
+				start:  start,
+				end:    end,
+			})
+		}
+
+		// Forget spans that scrolled off the screen
+		kept := e.linkSpans[:0]
+		for _, s := range e.linkSpans {
+			if s.absRow >= e.scrolledLines {
+				kept = append(kept, s)
+			}
+		}
+		e.linkSpans = kept
+	}
+
+	params, url, _ := strings.Cut(text, ";")
+	if url == "" {
+		return
+	}
+	id := ""
+	for _, p := range strings.Split(params, ":") {
+		if strings.HasPrefix(p, "id=") {
+			id = p[3:]
+		}
+	}
+	e.openLink = &openLink{url: url, id: id, absRow: absRow, col: col}
+}
+
+// spanText returns the text in columns [start, end) of a screen row.
+// The caller must hold e.mu.
+func (e *Emulator) spanText(row, start, end int) string {
+	cols := columns(e.getLine(row))
+	var b strings.Builder
+	for col := start; col < end; col++ {
+		switch {
+		case col >= len(cols):
+			b.WriteRune(' ')
+		case cols[col] != 0:
+			b.WriteRune(cols[col])
+		}
+	}
+	return b.String()
+}
+
+// GetLineHyperlinks returns the OSC 8 hyperlinks on a row (0-based), ordered by
+// column. A link written across several rows is reported on each row it covers.
+// Links are located by the cursor position where they start and end, which
+// matches how CLIs print them; links whose cells have since been overwritten
+// are not reported.
+func (e *Emulator) GetLineHyperlinks(row int) ([]Hyperlink, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.screen == nil {
+		return nil, errors.New("emulator not started")
+	}
+	if row < 0 || row >= int(e.rows) {
+		return nil, fmt.Errorf("row %d out of range for %d rows", row, e.rows)
+	}
+
+	var links []Hyperlink
+	taken := make([]bool, e.cols)
+	absRow := row + e.scrolledLines
+
+	// Newer spans take precedence over older ones covering the same cells
+	for i := len(e.linkSpans) - 1; i >= 0; i-- {
+		s := e.linkSpans[i]
+		if s.absRow != absRow || s.end > int(e.cols) || s.text != e.spanText(row, s.start, s.end) {
+			continue
+		}
+		overlaps := false
+		for col := s.start; col < s.end; col++ {
+			overlaps = overlaps || taken[col]
+			taken[col] = true
+		}
+		if !overlaps {
+			links = append(links, s.link)
+		}
+	}
+
+	sort.Slice(links, func(i, j int) bool { return links[i].StartCol < links[j].StartCol })
+	return links, nil
+}
@@ -0,0 +1,56 @@
+package vtermtest_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/c-bata/vtermtest"
+)
+
+func TestGetLineHyperlinks(t *testing.T) {
+	ctx := context.Background()
+
+	// Row 0 has two links, one with an id; row 1's link wraps onto row 2;
+	// row 3's link is overwritten afterwards
+	script := `printf 'see \033]8;;https://example.com\033\\docs\033]8;;\033\\ and \033]8;id=x1;https://go.dev\007go\033]8;;\007\n'
+printf 'abcdefghijklmnop\033]8;;https://wrap.example\007linktext\033]8;;\007\n'
+printf '\033]8;;https://gone.example\007gone\033]8;;\007\rxxxx'
+sleep 5`
+	emu := vtermtest.New(5, 20).
+		Command("sh", "-c", script).
+		Env("LANG=C.UTF-8")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	emu.AssertLineEqual(t, 3, "xxxx")
+
+	tests := []struct {
+		row  int
+		want []vtermtest.Hyperlink
+	}{
+		{0, []vtermtest.Hyperlink{
+			{URL: "https://example.com", StartCol: 4, EndCol: 8},
+			{URL: "https://go.dev", ID: "x1", StartCol: 13, EndCol: 15},
+		}},
+		{1, []vtermtest.Hyperlink{{URL: "https://wrap.example", StartCol: 16, EndCol: 20}}},
+		{2, []vtermtest.Hyperlink{{URL: "https://wrap.example", StartCol: 0, EndCol: 4}}},
+		{3, nil},
+	}
+	for _, tt := range tests {
+		got, err := emu.GetLineHyperlinks(tt.row)
+		if err != nil {
+			t.Fatalf("GetLineHyperlinks(%d): %v", tt.row, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("row %d: got %+v, want %+v", tt.row, got, tt.want)
+		}
+	}
+
+	if _, err := emu.GetLineHyperlinks(5); err == nil {
+		t.Error("expected an error for a row out of range")
+	}
+}
@@ -0,0 +1,113 @@
+package vtermtest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// errInputQueueUnsupported is returned by pendingInput on platforms where the
+// child's unread input cannot be queried.
+var errInputQueueUnsupported = errors.New("input queue query not supported")
+
+// WaitInputConsumed waits until the program has read all input sent to it, so a
+// following WaitStable or assertion observes the program's reaction rather than
+// a screen from before it took the bytes. Returns an error on timeout.
+//
+// On Linux the terminal's unread input queue is queried directly. In canonical
+// (cooked) mode only complete lines count as readable, so a partial line is
+// reported as consumed; it cannot be read by the program until Enter anyway.
+// On other platforms it falls back to waiting for output to be quiet for 50ms,
+// which only approximates consumption.
+func (e *Emulator) WaitInputConsumed(timeout time.Duration) error {
+	if e.ptmx == nil {
+		return errors.New("emulator not started")
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		n, err := pendingInput(e.ptmx)
+		if errors.Is(err, errInputQueueUnsupported) {
+			if !e.WaitStable(50*time.Millisecond, time.Until(deadline)) {
+				return errors.New("output did not go quiet within timeout")
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to query input queue: %w", err)
+		}
+		if n == 0 {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%d input bytes not consumed within timeout", n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// InputChan returns a channel whose values are written to the PTY in the order
+// they are sent, as if passed to KeyPress. It lets a goroutine drive input in
+// reaction to the screen while the test goroutine asserts. The writer is started
+// on the first call; later calls return the same channel.
+//
+// The channel is unbuffered, so a send returns once the writer has taken the
+// value. Stop sending before calling Close: Close stops the writer, and any send
+// after that blocks forever. After the first write error further values are
+// discarded, and the error is reported by Close.
+func (e *Emulator) InputChan() chan<- []byte {
+	e.inputOnce.Do(func() {
+		stop := make(chan struct{})
+		done := make(chan struct{})
+		e.inputCh = make(chan []byte)
+		e.mu.Lock()
+		e.inputStop = stop
+		e.inputDone = done
+		e.mu.Unlock()
+		go e.inputLoop(stop, done)
+	})
+	return e.inputCh
+}
+
+func (e *Emulator) inputLoop(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	for {
+		select {
+		case <-stop:
+			return
+		case key := <-e.inputCh:
+			if e.inputErr != nil {
+				continue // discard input after a failed write
+			}
+			if err := e.KeyPress(key); err != nil {
+				select {
+				case <-stop:
+					return // the PTY was closed under a pending write
+				default:
+					e.inputErr = err
+				}
+			}
+		}
+	}
+}
+
+// Stdin returns an io.Writer that writes to the PTY, so input built
+// incrementally can be streamed with io.Copy or fmt.Fprintf. Each Write is
+// passed to WriteRaw: bytes go to the program verbatim, without DSL parsing or
+// key overrides. Writes do not take the screen lock, so they never wait on the
+// read loop, and concurrent writes are each written whole, in some order.
+// Writes block while the program is not reading its input.
+func (e *Emulator) Stdin() io.Writer {
+	return stdinWriter{e}
+}
+
+type stdinWriter struct {
+	e *Emulator
+}
+
+func (w stdinWriter) Write(p []byte) (int, error) {
+	return w.e.WriteRaw(p)
+}
@@ -0,0 +1,40 @@
+//go:build linux
+// +build linux
+
+package vtermtest
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// pendingInput returns the number of input bytes the program has not read yet.
+// It opens the PTY's terminal side briefly, since only that side reports the
+// input queue; keeping it open would prevent EOF when the program exits.
+func pendingInput(ptmx *os.File) (int, error) {
+	var ptn uint32
+	if err := ioctl(ptmx.Fd(), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&ptn))); err != nil {
+		return 0, err
+	}
+
+	tty, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", ptn), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer tty.Close()
+
+	var n int32
+	if err := ioctl(tty.Fd(), syscall.TIOCINQ, uintptr(unsafe.Pointer(&n))); err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
+
+func ioctl(fd, req, arg uintptr) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, req, arg); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package vtermtest
+
+import "os"
+
+func pendingInput(ptmx *os.File) (int, error) {
+	return 0, errInputQueueUnsupported
+}
@@ -0,0 +1,41 @@
+//go:build linux
+// +build linux
+
+package vtermtest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/c-bata/vtermtest"
+	"github.com/c-bata/vtermtest/keys"
+)
+
+func TestWaitInputConsumed(t *testing.T) {
+	ctx := context.Background()
+
+	// The program starts reading only after a delay
+	emu := vtermtest.New(4, 40).
+		Command("sh", "-c", "stty raw -echo; sleep 0.3; head -c 3 > /dev/null; echo done; sleep 5").
+		Env("LANG=C.UTF-8")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	time.Sleep(100 * time.Millisecond)
+	start := time.Now()
+	if err := emu.KeyPress(keys.Text("abc")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := emu.WaitInputConsumed(5 * time.Second); err != nil {
+		t.Fatalf("WaitInputConsumed failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("returned after %v, before the program read its input", elapsed)
+	}
+	emu.AssertScreenContains(t, "done")
+}
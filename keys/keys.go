@@ -45,12 +45,53 @@ var (
 
 	// Device Status Report (DSR) sequences
 	DSR = []byte{0x1B, 0x5B, 0x36, 0x6E} // ESC[6n - Request cursor position
+
+	// Enquiry (ENQ), answered by terminals with their answerback message
+	ENQ = []byte{0x05}
+
+	// Focus reporting sequences (sent when the program enables DECSET 1004)
+	FocusIn  = []byte{0x1B, 0x5B, 0x49} // ESC[I
+	FocusOut = []byte{0x1B, 0x5B, 0x4F} // ESC[O
+
+	escape = []byte{0x1B}
+	space  = []byte{' '}
 )
 
+// ctrlKeys holds CtrlA-CtrlZ, returned for DSL tags <C-a> to <C-z>.
+var ctrlKeys = [26][]byte{
+	CtrlA, CtrlB, CtrlC, CtrlD, CtrlE, CtrlF, CtrlG, CtrlH, CtrlI, CtrlJ, CtrlK, CtrlL, CtrlM,
+	CtrlN, CtrlO, CtrlP, CtrlQ, CtrlR, CtrlS, CtrlT, CtrlU, CtrlV, CtrlW, CtrlX, CtrlY, CtrlZ,
+}
+
+// Chord joins several key sequences into one, so they are sent in a single write.
+// Example: Chord(CtrlA, CtrlK) sends Ctrl-A immediately followed by Ctrl-K.
+func Chord(keys ...[]byte) []byte {
+	var chord []byte
+	for _, key := range keys {
+		chord = append(chord, key...)
+	}
+	return chord
+}
+
 func Text(s string) []byte {
 	return []byte(s)
 }
 
+// Literal returns s as-is, for text containing DSL tag delimiters such as JSON or HTML.
+// Keys passed to KeyPress are never interpreted, so Literal is equivalent to Text;
+// within a DSL string, use <Raw>...</Raw> instead.
+func Literal(s string) []byte {
+	return []byte(s)
+}
+
+// Paste returns text wrapped in bracketed paste markers (ESC[200~ ... ESC[201~),
+// as a terminal sends pasted text to programs that enabled bracketed paste mode
+// (DECSET 2004). Such programs insert the text as-is instead of acting on each
+// line, e.g. a REPL does not run each line of a pasted snippet.
+func Paste(text string) []byte {
+	return []byte("\x1b[200~" + text + "\x1b[201~")
+}
+
 // Alt returns Alt+key combination
 func Alt(key rune) []byte {
 	return []byte{0x1B, byte(key)}
@@ -67,61 +108,52 @@ var (
 	AltRight = []byte{0x1B, 0x5B, 0x31, 0x3B, 0x33, 0x43}
 )
 
-func F(n int) []byte {
-	if n < 1 || n > 24 {
-		return nil
+// altKeys holds the Alt+letter sequences returned for DSL tags such as <A-a>,
+// reusing AltA to AltF.
+var altKeys = func() map[rune][]byte {
+	m := map[rune][]byte{'a': AltA, 'b': AltB, 'c': AltC, 'd': AltD, 'f': AltF}
+	for ch := 'a'; ch <= 'z'; ch++ {
+		if _, ok := m[ch]; !ok {
+			m[ch] = Alt(ch)
+		}
+		m[ch-'a'+'A'] = Alt(ch - 'a' + 'A')
 	}
+	return m
+}()
 
-	switch n {
-	case 1:
-		return []byte{0x1B, 0x4F, 0x50}
-	case 2:
-		return []byte{0x1B, 0x4F, 0x51}
-	case 3:
-		return []byte{0x1B, 0x4F, 0x52}
-	case 4:
-		return []byte{0x1B, 0x4F, 0x53}
-	case 5:
-		return []byte{0x1B, 0x5B, 0x31, 0x35, 0x7E}
-	case 6:
-		return []byte{0x1B, 0x5B, 0x31, 0x37, 0x7E}
-	case 7:
-		return []byte{0x1B, 0x5B, 0x31, 0x38, 0x7E}
-	case 8:
-		return []byte{0x1B, 0x5B, 0x31, 0x39, 0x7E}
-	case 9:
-		return []byte{0x1B, 0x5B, 0x32, 0x30, 0x7E}
-	case 10:
-		return []byte{0x1B, 0x5B, 0x32, 0x31, 0x7E}
-	case 11:
-		return []byte{0x1B, 0x5B, 0x32, 0x33, 0x7E}
-	case 12:
-		return []byte{0x1B, 0x5B, 0x32, 0x34, 0x7E}
-	case 13:
-		return []byte{0x1B, 0x5B, 0x31, 0x3B, 0x32, 0x50}
-	case 14:
-		return []byte{0x1B, 0x5B, 0x31, 0x3B, 0x32, 0x51}
-	case 15:
-		return []byte{0x1B, 0x5B, 0x31, 0x3B, 0x32, 0x52}
-	case 16:
-		return []byte{0x1B, 0x5B, 0x31, 0x3B, 0x32, 0x53}
-	case 17:
-		return []byte{0x1B, 0x5B, 0x31, 0x35, 0x3B, 0x32, 0x7E}
-	case 18:
-		return []byte{0x1B, 0x5B, 0x31, 0x37, 0x3B, 0x32, 0x7E}
-	case 19:
-		return []byte{0x1B, 0x5B, 0x31, 0x38, 0x3B, 0x32, 0x7E}
-	case 20:
-		return []byte{0x1B, 0x5B, 0x31, 0x39, 0x3B, 0x32, 0x7E}
-	case 21:
-		return []byte{0x1B, 0x5B, 0x32, 0x30, 0x3B, 0x32, 0x7E}
-	case 22:
-		return []byte{0x1B, 0x5B, 0x32, 0x31, 0x3B, 0x32, 0x7E}
-	case 23:
-		return []byte{0x1B, 0x5B, 0x32, 0x33, 0x3B, 0x32, 0x7E}
-	case 24:
-		return []byte{0x1B, 0x5B, 0x32, 0x34, 0x3B, 0x32, 0x7E}
-	default:
+// fKeys holds the sequences for F1-F24, shared by F and DSL tags.
+var fKeys = [24][]byte{
+	{0x1B, 0x4F, 0x50},
+	{0x1B, 0x4F, 0x51},
+	{0x1B, 0x4F, 0x52},
+	{0x1B, 0x4F, 0x53},
+	{0x1B, 0x5B, 0x31, 0x35, 0x7E},
+	{0x1B, 0x5B, 0x31, 0x37, 0x7E},
+	{0x1B, 0x5B, 0x31, 0x38, 0x7E},
+	{0x1B, 0x5B, 0x31, 0x39, 0x7E},
+	{0x1B, 0x5B, 0x32, 0x30, 0x7E},
+	{0x1B, 0x5B, 0x32, 0x31, 0x7E},
+	{0x1B, 0x5B, 0x32, 0x33, 0x7E},
+	{0x1B, 0x5B, 0x32, 0x34, 0x7E},
+	{0x1B, 0x5B, 0x31, 0x3B, 0x32, 0x50},
+	{0x1B, 0x5B, 0x31, 0x3B, 0x32, 0x51},
+	{0x1B, 0x5B, 0x31, 0x3B, 0x32, 0x52},
+	{0x1B, 0x5B, 0x31, 0x3B, 0x32, 0x53},
+	{0x1B, 0x5B, 0x31, 0x35, 0x3B, 0x32, 0x7E},
+	{0x1B, 0x5B, 0x31, 0x37, 0x3B, 0x32, 0x7E},
+	{0x1B, 0x5B, 0x31, 0x38, 0x3B, 0x32, 0x7E},
+	{0x1B, 0x5B, 0x31, 0x39, 0x3B, 0x32, 0x7E},
+	{0x1B, 0x5B, 0x32, 0x30, 0x3B, 0x32, 0x7E},
+	{0x1B, 0x5B, 0x32, 0x31, 0x3B, 0x32, 0x7E},
+	{0x1B, 0x5B, 0x32, 0x33, 0x3B, 0x32, 0x7E},
+	{0x1B, 0x5B, 0x32, 0x34, 0x3B, 0x32, 0x7E},
+}
+
+// F returns the sequence for function key Fn (1-24), or nil if n is out of range.
+// The returned slice is shared; do not modify it.
+func F(n int) []byte {
+	if n < 1 || n > 24 {
 		return nil
 	}
-}
\ No newline at end of file
+	return fKeys[n-1]
+}
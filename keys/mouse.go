@@ -0,0 +1,90 @@
+package keys
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MouseButton identifies a mouse button in SGR mouse reports.
+type MouseButton int
+
+// Mouse buttons, numbered as in SGR mouse reports.
+const (
+	MouseLeft   MouseButton = 0
+	MouseMiddle MouseButton = 1
+	MouseRight  MouseButton = 2
+)
+
+// Wheel buttons used by MouseScroll
+const (
+	mouseWheelUp   = 64
+	mouseWheelDown = 65
+)
+
+// sgrMouse encodes one SGR (DECSET 1006) mouse report. Row and col are 0-based;
+// the report is 1-based.
+func sgrMouse(button, row, col int, release bool) []byte {
+	final := 'M'
+	if release {
+		final = 'm'
+	}
+	return []byte(fmt.Sprintf("\x1b[<%d;%d;%d%c", button, col+1, row+1, final))
+}
+
+// MouseClick returns a press and release of button at row and col (0-based, as in
+// Emulator.GetLine), encoded as SGR mouse reports (ESC [ < b ; x ; y M/m).
+// The program must have enabled mouse reporting (e.g. DECSET 1000) with SGR
+// encoding (DECSET 1006); otherwise it sees the bytes as ordinary input.
+func MouseClick(row, col int, button MouseButton) []byte {
+	return Chord(sgrMouse(int(button), row, col, false), sgrMouse(int(button), row, col, true))
+}
+
+// MouseScroll returns wheel events at row and col (0-based): one per line,
+// scrolling down for positive lines and up for negative ones. Like MouseClick it
+// uses SGR encoding, which the program must have enabled.
+func MouseScroll(row, col, lines int) []byte {
+	button := mouseWheelDown
+	if lines < 0 {
+		button, lines = mouseWheelUp, -lines
+	}
+
+	var events []byte
+	for i := 0; i < lines; i++ {
+		events = append(events, sgrMouse(button, row, col, false)...)
+	}
+	return events
+}
+
+// parseMouse parses the arguments of a <Mouse row,col button> tag, where button is
+// Left, Middle, Right, ScrollUp or ScrollDown.
+func parseMouse(name, args string) ([]byte, error) {
+	fields := strings.Fields(args)
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("invalid mouse event: <%s> (want <Mouse row,col button>)", name)
+	}
+
+	pos := strings.Split(fields[0], ",")
+	if len(pos) != 2 {
+		return nil, fmt.Errorf("invalid mouse position: <%s>", name)
+	}
+	row, err1 := strconv.Atoi(pos[0])
+	col, err2 := strconv.Atoi(pos[1])
+	if err1 != nil || err2 != nil || row < 0 || col < 0 {
+		return nil, fmt.Errorf("invalid mouse position: <%s>", name)
+	}
+
+	switch strings.ToLower(fields[1]) {
+	case "left":
+		return MouseClick(row, col, MouseLeft), nil
+	case "middle":
+		return MouseClick(row, col, MouseMiddle), nil
+	case "right":
+		return MouseClick(row, col, MouseRight), nil
+	case "scrollup":
+		return MouseScroll(row, col, -1), nil
+	case "scrolldown":
+		return MouseScroll(row, col, 1), nil
+	}
+	return nil, fmt.Errorf("invalid mouse button: <%s> (valid: Left, Middle, Right, ScrollUp, ScrollDown)", name)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 )
 
@@ -13,6 +14,11 @@ type ParseOptions struct {
 	TagStart rune
 	// TagEnd is the character that ends a special key tag (default: '>')
 	TagEnd rune
+	// IgnoreSpacesBetweenTags drops text made only of spaces when it sits between
+	// two tags, so "<C-a> <C-k>" sends Ctrl-A then Ctrl-K (default: false).
+	// Text containing anything else is kept verbatim, including its spaces:
+	// "<C-a> x <C-k>" still sends " x ". Use <Space> for a literal space between tags.
+	IgnoreSpacesBetweenTags bool
 }
 
 // DefaultParseOptions returns the default parsing options.
@@ -34,6 +40,12 @@ func DefaultParseOptions() ParseOptions {
 //   - Alt keys: <A-a> ... <A-z>
 //   - Function keys: <F1> ... <F24>
 //   - Navigation: <Home> <End> <PageUp> <PageDown>
+//   - Focus events: <FocusIn> <FocusOut>
+//   - Mouse events: <Mouse row,col Left|Middle|Right|ScrollUp|ScrollDown> (0-based, SGR encoding)
+//   - Step labels: <Label name> sends nothing; later failures report the step name
+//   - Pauses: <Sleep 200ms> waits for a duration in time.ParseDuration format
+//   - Raw text: <Raw>...</Raw> sends everything up to the first </Raw> verbatim
+//   - Pasted text: <Paste>...<EndPaste> sends the text verbatim as a bracketed paste
 //   - Escape: << for literal <
 func Parse(dsl string) ([][]byte, error) {
 	return ParseWithOptions(dsl, DefaultParseOptions())
@@ -44,6 +56,7 @@ func Parse(dsl string) ([][]byte, error) {
 func ParseWithOptions(dsl string, opts ParseOptions) ([][]byte, error) {
 	var result [][]byte
 	var text strings.Builder
+	afterTag := false
 
 	tagStartByte := byte(opts.TagStart)
 	tagEndByte := byte(opts.TagEnd)
@@ -57,6 +70,11 @@ func ParseWithOptions(dsl string, opts ParseOptions) ([][]byte, error) {
 				continue
 			}
 
+			// Drop spacing between tags when requested
+			if opts.IgnoreSpacesBetweenTags && afterTag && strings.Trim(text.String(), " ") == "" {
+				text.Reset()
+			}
+
 			// Flush accumulated text
 			if text.Len() > 0 {
 				result = append(result, Text(text.String()))
@@ -76,12 +94,41 @@ func ParseWithOptions(dsl string, opts ParseOptions) ([][]byte, error) {
 			}
 
 			keyName := dsl[i+1 : i+1+end]
+			if strings.EqualFold(keyName, "raw") {
+				// Raw text runs until the first closing tag, e.g. </Raw> or [/Raw]
+				start := i + end + 2
+				closeTag := string(opts.TagStart) + "/Raw" + string(opts.TagEnd)
+				n := indexFold(dsl[start:], closeTag)
+				if n == -1 {
+					return nil, fmt.Errorf("unclosed %s at position %d", dsl[i:start], i)
+				}
+				text.WriteString(dsl[start : start+n])
+				afterTag = false
+				i = start + n + len(closeTag) - 1
+				continue
+			}
+
+			if strings.EqualFold(keyName, "paste") {
+				// Pasted text runs until the first end tag, e.g. <EndPaste> or [EndPaste]
+				start := i + end + 2
+				endTag := string(opts.TagStart) + "EndPaste" + string(opts.TagEnd)
+				n := indexFold(dsl[start:], endTag)
+				if n == -1 {
+					return nil, fmt.Errorf("unclosed %s at position %d", dsl[i:start], i)
+				}
+				result = append(result, Paste(dsl[start:start+n]))
+				afterTag = true
+				i = start + n + len(endTag) - 1
+				continue
+			}
+
 			key, err := parseSpecialKey(keyName)
 			if err != nil {
 				return nil, fmt.Errorf("at position %d: %w", i, err)
 			}
 
 			result = append(result, key)
+			afterTag = true
 			i += end + 1 // Skip past the tag end
 		} else {
 			text.WriteByte(dsl[i])
@@ -96,6 +143,24 @@ func ParseWithOptions(dsl string, opts ParseOptions) ([][]byte, error) {
 	return result, nil
 }
 
+// indexFold returns the index of the first case-insensitive match of substr in s, or -1.
+func indexFold(s, substr string) int {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Lookup returns the default byte sequence for a key name as used in DSL tags
+// (e.g. "Tab", "C-a", "F5"). Names are case-insensitive. Named keys return the
+// package's shared value for the key (e.g. Tab for "Tab", CtrlA for "C-a"), the
+// same slice the DSL produces for the tag; do not modify it.
+func Lookup(name string) ([]byte, error) {
+	return parseSpecialKey(name)
+}
+
 func parseSpecialKey(name string) ([]byte, error) {
 	// Handle basic special keys
 	switch strings.ToLower(name) {
@@ -108,9 +173,9 @@ func parseSpecialKey(name string) ([]byte, error) {
 	case "del", "delete":
 		return Delete, nil
 	case "esc", "escape":
-		return []byte{0x1B}, nil
+		return escape, nil
 	case "space":
-		return []byte{' '}, nil
+		return space, nil
 	case "up":
 		return Up, nil
 	case "down":
@@ -127,6 +192,10 @@ func parseSpecialKey(name string) ([]byte, error) {
 		return PageUp, nil
 	case "pagedown":
 		return PageDown, nil
+	case "focusin":
+		return FocusIn, nil
+	case "focusout":
+		return FocusOut, nil
 	case "waitstable":
 		return []byte("__WAITSTABLE__"), nil
 	}
@@ -137,11 +206,32 @@ func parseSpecialKey(name string) ([]byte, error) {
 		return []byte("__WAITFOR__" + text), nil
 	}
 
+	// Handle Sleep with a duration, validated here so scripts fail before sending anything
+	if strings.HasPrefix(strings.ToLower(name), "sleep ") {
+		arg := strings.TrimSpace(name[6:]) // Remove "sleep " prefix
+		d, err := time.ParseDuration(arg)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid sleep duration: <%s>", name)
+		}
+		return []byte("__SLEEP__" + d.String()), nil
+	}
+
+	// Handle Mouse events (e.g. Mouse 10,20 Left)
+	if strings.HasPrefix(strings.ToLower(name), "mouse ") {
+		return parseMouse(name, name[6:])
+	}
+
+	// Handle Label with step name
+	if strings.HasPrefix(strings.ToLower(name), "label ") {
+		label := strings.TrimSpace(name[6:]) // Remove "label " prefix
+		return []byte("__LABEL__" + label), nil
+	}
+
 	// Handle Ctrl-X format (C-a, C-b, etc.)
 	if strings.HasPrefix(strings.ToLower(name), "c-") && len(name) == 3 {
 		ch := unicode.ToLower(rune(name[2]))
 		if ch >= 'a' && ch <= 'z' {
-			return []byte{byte(ch - 'a' + 1)}, nil
+			return ctrlKeys[ch-'a'], nil
 		}
 		return nil, fmt.Errorf("invalid ctrl key: <%s>", name)
 	}
@@ -150,8 +240,8 @@ func parseSpecialKey(name string) ([]byte, error) {
 	if strings.HasPrefix(strings.ToLower(name), "a-") && len(name) == 3 {
 		ch := rune(name[2])
 		// Only allow letters for Alt combinations
-		if (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') {
-			return Alt(ch), nil
+		if key, ok := altKeys[ch]; ok {
+			return key, nil
 		}
 		return nil, fmt.Errorf("invalid alt key: <%s>", name)
 	}
@@ -190,11 +190,22 @@ func TestParseSpecialKey(t *testing.T) {
 		{"end", "end", End, false},
 		{"pageup", "pageup", PageUp, false},
 		{"pagedown", "pagedown", PageDown, false},
+		{"focusin", "FocusIn", FocusIn, false},
+		{"focusout", "FocusOut", FocusOut, false},
+		{"label", "Label open menu", []byte("__LABEL__open menu"), false},
+		{"sleep", "Sleep 200ms", []byte("__SLEEP__200ms"), false},
+		{"sleep-compound", "sleep 1m30s", []byte("__SLEEP__1m30s"), false},
 
 		// Error cases
 		{"unknown", "unknown", nil, true},
 		{"invalid-ctrl", "C-1", nil, true},
 		{"invalid-alt", "A-1", nil, true},
+		{"mouse", "Mouse 2,9 Left", []byte("\x1b[<0;10;3M\x1b[<0;10;3m"), false},
+		{"mouse-scroll", "mouse 0,0 scrollup", []byte("\x1b[<64;1;1M"), false},
+		{"invalid-mouse-button", "Mouse 2,9 Back", nil, true},
+		{"invalid-mouse-position", "Mouse 2 Left", nil, true},
+		{"invalid-sleep", "Sleep soon", nil, true},
+		{"negative-sleep", "Sleep -1s", nil, true},
 		{"invalid-function", "F25", nil, true},
 		{"invalid-function-format", "Fabc", nil, true},
 		{"empty", "", nil, true},
@@ -269,3 +280,190 @@ func TestDSLExamples(t *testing.T) {
 		})
 	}
 }
+
+func TestParseIgnoreSpacesBetweenTags(t *testing.T) {
+	opts := DefaultParseOptions()
+	opts.IgnoreSpacesBetweenTags = true
+
+	tests := []struct {
+		name     string
+		input    string
+		expected [][]byte
+	}{
+		{
+			name:     "spaces between tags dropped",
+			input:    "<C-a> <C-k>   <Enter>",
+			expected: [][]byte{CtrlA, CtrlK, Enter},
+		},
+		{
+			name:     "text between tags kept verbatim",
+			input:    "<C-a> x <C-k>",
+			expected: [][]byte{CtrlA, Text(" x "), CtrlK},
+		},
+		{
+			name:     "leading and trailing spaces kept",
+			input:    " <Tab> ",
+			expected: [][]byte{Text(" "), Tab, Text(" ")},
+		},
+		{
+			name:     "explicit space tag",
+			input:    "<C-a> <Space> <C-k>",
+			expected: [][]byte{CtrlA, []byte{' '}, CtrlK},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseWithOptions(tt.input, opts)
+			if err != nil {
+				t.Fatalf("ParseWithOptions() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ParseWithOptions() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+
+	// Default options keep the spaces
+	result, err := Parse("<C-a> <C-k>")
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(result, [][]byte{CtrlA, Text(" "), CtrlK}) {
+		t.Errorf("Parse() = %v, expected spaces preserved by default", result)
+	}
+}
+
+func TestParseRaw(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     ParseOptions
+		expected [][]byte
+	}{
+		{
+			name:     "html",
+			input:    "<Raw><b>bold</b></Raw><Enter>",
+			opts:     DefaultParseOptions(),
+			expected: [][]byte{Text("<b>bold</b>"), Enter},
+		},
+		{
+			name:     "merged with surrounding text",
+			input:    "echo <raw>{\"a\": \"<<\"}</RAW>!",
+			opts:     DefaultParseOptions(),
+			expected: [][]byte{Text("echo "), Text("{\"a\": \"<<\"}!")},
+		},
+		{
+			name:     "custom delimiters",
+			input:    "[Raw]a[b]c[/Raw][Tab]",
+			opts:     ParseOptions{TagStart: '[', TagEnd: ']'},
+			expected: [][]byte{Text("a[b]c"), Tab},
+		},
+		{
+			name:     "empty",
+			input:    "<Raw></Raw>",
+			opts:     DefaultParseOptions(),
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseWithOptions(tt.input, tt.opts)
+			if err != nil {
+				t.Fatalf("ParseWithOptions() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ParseWithOptions() = %q, expected %q", result, tt.expected)
+			}
+		})
+	}
+
+	if _, err := Parse("<Raw><b>"); err == nil {
+		t.Error("Parse() expected error for unclosed <Raw>")
+	}
+}
+
+func TestChord(t *testing.T) {
+	if got := Chord(CtrlA, CtrlK); !bytes.Equal(got, []byte{0x01, 0x0B}) {
+		t.Errorf("Chord(CtrlA, CtrlK) = %v", got)
+	}
+	if got := Chord(); len(got) != 0 {
+		t.Errorf("Chord() = %v, expected empty", got)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	key, err := Lookup("PageUp")
+	if err != nil {
+		t.Fatalf("Lookup() unexpected error: %v", err)
+	}
+	if !bytes.Equal(key, PageUp) {
+		t.Errorf("Lookup(PageUp) = %v, expected %v", key, PageUp)
+	}
+	if _, err := Lookup("NoSuchKey"); err == nil {
+		t.Error("Lookup() expected error for unknown key")
+	}
+
+	// Named keys are the package's shared values, so they can be told apart
+	// from text with the same bytes
+	for name, want := range map[string][]byte{"Tab": Tab, "C-a": CtrlA, "A-b": AltB, "F5": F(5)} {
+		key, err := Lookup(name)
+		if err != nil || &key[0] != &want[0] {
+			t.Errorf("Lookup(%q) did not return the shared key value", name)
+		}
+	}
+}
+
+func TestMouse(t *testing.T) {
+	if got := MouseClick(0, 4, MouseRight); string(got) != "\x1b[<2;5;1M\x1b[<2;5;1m" {
+		t.Errorf("MouseClick(0, 4, MouseRight) = %q", got)
+	}
+	if got := MouseScroll(3, 1, 2); string(got) != "\x1b[<65;2;4M\x1b[<65;2;4M" {
+		t.Errorf("MouseScroll(3, 1, 2) = %q", got)
+	}
+	if got := MouseScroll(3, 1, -1); string(got) != "\x1b[<64;2;4M" {
+		t.Errorf("MouseScroll(3, 1, -1) = %q", got)
+	}
+}
+
+func TestParsePaste(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     ParseOptions
+		expected [][]byte
+	}{
+		{
+			name:     "multi-line snippet",
+			input:    "x = <Paste>def f():\n    return <1>\n<EndPaste><Enter>",
+			opts:     DefaultParseOptions(),
+			expected: [][]byte{Text("x = "), Paste("def f():\n    return <1>\n"), Enter},
+		},
+		{
+			name:     "custom delimiters",
+			input:    "[paste]a[b][endpaste]",
+			opts:     ParseOptions{TagStart: '[', TagEnd: ']'},
+			expected: [][]byte{Paste("a[b]")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseWithOptions(tt.input, tt.opts)
+			if err != nil {
+				t.Fatalf("ParseWithOptions() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ParseWithOptions() = %q, expected %q", result, tt.expected)
+			}
+		})
+	}
+
+	if _, err := Parse("<Paste>never closed"); err == nil {
+		t.Error("expected an error for an unclosed <Paste>")
+	}
+	if got := Paste("a\nb"); string(got) != "\x1b[200~a\nb\x1b[201~" {
+		t.Errorf("Paste() = %q", got)
+	}
+}
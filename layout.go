@@ -0,0 +1,70 @@
+package vtermtest
+
+import "strings"
+
+// FindVerticalDivider returns the first column (0-based, in display cells) in
+// which every row holds ch, such as the '│' between two side-by-side panes.
+// Compare the result with the terminal width to check proportional layouts.
+func (e *Emulator) FindVerticalDivider(ch rune) (col int, ok bool) {
+	screen, err := e.getScreenText()
+	if err != nil {
+		return 0, false
+	}
+	return verticalDivider(screen, ch)
+}
+
+// FindHorizontalDivider returns the first row (0-based) that is entirely ch
+// across the terminal width, such as a '─' line between stacked panes.
+func (e *Emulator) FindHorizontalDivider(ch rune) (row int, ok bool) {
+	screen, err := e.getScreenText()
+	if err != nil {
+		return 0, false
+	}
+
+	e.mu.Lock()
+	width := int(e.cols)
+	e.mu.Unlock()
+
+	return horizontalDivider(screen, ch, width)
+}
+
+func verticalDivider(screen string, ch rune) (int, bool) {
+	var grid [][]rune
+	for _, line := range strings.Split(screen, "\n") {
+		grid = append(grid, columns(line))
+	}
+
+	for col := 0; len(grid) > 0 && col < len(grid[0]); col++ {
+		found := true
+		for _, cells := range grid {
+			if col >= len(cells) || cells[col] != ch {
+				found = false
+				break
+			}
+		}
+		if found {
+			return col, true
+		}
+	}
+	return 0, false
+}
+
+func horizontalDivider(screen string, ch rune, width int) (int, bool) {
+	for row, line := range strings.Split(screen, "\n") {
+		cells := columns(line)
+		if len(cells) != width {
+			continue
+		}
+		found := true
+		for _, c := range cells {
+			if c != ch {
+				found = false
+				break
+			}
+		}
+		if found {
+			return row, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,43 @@
+package vtermtest
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDividers(t *testing.T) {
+	screen := "ab│cd\n─────\nef│gh"
+	if col, ok := verticalDivider(screen, '│'); ok {
+		t.Errorf("expected no vertical divider across all rows, got col %d", col)
+	}
+	if row, ok := horizontalDivider(screen, '─', 5); !ok || row != 1 {
+		t.Errorf("horizontalDivider = %d, %v; want 1, true", row, ok)
+	}
+	if _, ok := horizontalDivider(screen, '─', 6); ok {
+		t.Error("expected a short line not to count as a full-width divider")
+	}
+
+	screen = "日本│x\nab  │cd\n    │"
+	if col, ok := verticalDivider(screen, '│'); !ok || col != 4 {
+		t.Errorf("verticalDivider = %d, %v; want 4, true", col, ok)
+	}
+}
+
+func TestFindVerticalDivider(t *testing.T) {
+	emu := New(3, 20).
+		Command("sh", "-c", "printf 'left      |right\\nleft      |right\\n          |'; sleep 5")
+	t.Cleanup(func() { _ = emu.Close() })
+
+	if err := emu.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	emu.AssertLineEqual(t, 2, "          |")
+
+	col, ok := emu.FindVerticalDivider('|')
+	if !ok || col != 10 {
+		t.Errorf("FindVerticalDivider = %d, %v; want 10, true", col, ok)
+	}
+	if _, ok := emu.FindHorizontalDivider('-'); ok {
+		t.Error("expected no horizontal divider")
+	}
+}
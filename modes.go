@@ -0,0 +1,163 @@
+package vtermtest
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// DEC private modes commonly toggled by interactive programs.
+const (
+	ModeApplicationCursorKeys = 1    // DECCKM
+	ModeShowCursor            = 25   // DECTCEM
+	ModeMouseTracking         = 1000 // Mouse button press/release reporting
+	ModeFocusReporting        = 1004 // Focus in/out events
+	ModeSGRMouse              = 1006 // SGR mouse report encoding
+	ModeAltScreen             = 1049 // Alternate screen buffer
+	ModeBracketedPaste        = 2004 // Bracketed paste
+)
+
+// maxPendingMode bounds how much of an incomplete sequence is kept between reads.
+const maxPendingMode = 32
+
+// modeTracker follows DEC private modes set (CSI ? Pm h) and reset (CSI ? Pm l)
+// by the program. A full reset (RIS, ESC c) resets all of them. Sequences split
+// across reads are completed on the next feed.
+type modeTracker struct {
+	modes   map[int]bool
+	pending []byte
+}
+
+func (m *modeTracker) feed(data []byte) {
+	buf := data
+	if len(m.pending) > 0 {
+		buf = append(m.pending, data...)
+		m.pending = nil
+	}
+
+	for i := 0; i < len(buf); i++ {
+		if buf[i] != 0x1B {
+			continue
+		}
+
+		rest := buf[i:]
+		if len(rest) >= 2 && rest[1] == 'c' {
+			m.modes = nil
+			i++
+			continue
+		}
+		if len(rest) < 3 {
+			if bytes.HasPrefix([]byte("\x1b[?"), rest) {
+				m.pending = append([]byte(nil), rest...)
+			}
+			return
+		}
+		if rest[1] != '[' || rest[2] != '?' {
+			continue
+		}
+
+		j := 3
+		for j < len(rest) && (rest[j] == ';' || (rest[j] >= '0' && rest[j] <= '9')) {
+			j++
+		}
+		if j == len(rest) {
+			if len(rest) <= maxPendingMode {
+				m.pending = append([]byte(nil), rest...)
+			}
+			return
+		}
+
+		if final := rest[j]; final == 'h' || final == 'l' {
+			for _, param := range bytes.Split(rest[3:j], []byte{';'}) {
+				n, err := strconv.Atoi(string(param))
+				if err != nil {
+					continue
+				}
+				if m.modes == nil {
+					m.modes = make(map[int]bool)
+				}
+				m.modes[n] = final == 'h'
+			}
+		}
+		i += j
+	}
+}
+
+func (m *modeTracker) enabled(mode int) bool {
+	return m.modes[mode]
+}
+
+// enabledModes returns the currently enabled modes in ascending order.
+func (m *modeTracker) enabledModes() []int {
+	var modes []int
+	for mode, on := range m.modes {
+		if on {
+			modes = append(modes, mode)
+		}
+	}
+	sort.Ints(modes)
+	return modes
+}
+
+// ModeEnabled reports whether the program has enabled the given DEC private mode
+// (e.g. ModeApplicationCursorKeys, ModeFocusReporting) and not reset it since.
+func (e *Emulator) ModeEnabled(mode int) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.modes.enabled(mode)
+}
+
+// AssertModeEnabled asserts that the program has enabled the given DEC private mode.
+// It retries with exponential backoff, and reports the enabled modes on failure.
+func (e *Emulator) AssertModeEnabled(t TestingT, mode int) {
+	t.Helper()
+	e.assertMode(t, mode, true)
+}
+
+// AssertModeDisabled asserts that the given DEC private mode is not enabled,
+// e.g. that the program left the alternate screen on exit.
+func (e *Emulator) AssertModeDisabled(t TestingT, mode int) {
+	t.Helper()
+	e.assertMode(t, mode, false)
+}
+
+func (e *Emulator) assertMode(t TestingT, mode int, want bool) {
+	t.Helper()
+
+	e.assertWithRetry(t, func() error {
+		e.mu.Lock()
+		got := e.modes.enabled(mode)
+		enabled := e.modes.enabledModes()
+		e.mu.Unlock()
+
+		if got != want {
+			state := "disabled"
+			if want {
+				state = "enabled"
+			}
+			return fmt.Errorf("mode %d is not %s\nenabled modes: %v", mode, state, enabled)
+		}
+		return nil
+	})
+}
+
+// ApplicationCursorKeys reports whether the program has enabled application cursor
+// keys mode (DECSET 1). While enabled, KeyPress sends cursor keys as ESC O x.
+func (e *Emulator) ApplicationCursorKeys() bool {
+	return e.ModeEnabled(ModeApplicationCursorKeys)
+}
+
+// applicationCursorKey returns the application mode (SS3) form of a normal mode
+// cursor key sequence (ESC [ A-D, H, F), or nil if key is not a cursor key.
+func applicationCursorKey(key []byte) []byte {
+	if len(key) != 3 || key[0] != 0x1B || key[1] != '[' {
+		return nil
+	}
+	switch key[2] {
+	case 'A', 'B', 'C', 'D', 'H', 'F':
+		return []byte{0x1B, 'O', key[2]}
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package vtermtest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/c-bata/vtermtest/keys"
+)
+
+func TestModeTracker(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   map[int]bool
+	}{
+		{
+			name:   "set",
+			chunks: []string{"\x1b[?1h"},
+			want:   map[int]bool{1: true},
+		},
+		{
+			name:   "set then reset",
+			chunks: []string{"\x1b[?1h", "text", "\x1b[?1l"},
+			want:   map[int]bool{1: false},
+		},
+		{
+			name:   "multiple params",
+			chunks: []string{"\x1b[?1049;1004h"},
+			want:   map[int]bool{1049: true, 1004: true},
+		},
+		{
+			name:   "split across reads",
+			chunks: []string{"abc\x1b", "[?20", "04h"},
+			want:   map[int]bool{2004: true},
+		},
+		{
+			name:   "other sequences ignored",
+			chunks: []string{"\x1b[1h\x1b[2J\x1b[?25l"},
+			want:   map[int]bool{25: false, 1: false},
+		},
+		{
+			name:   "full reset",
+			chunks: []string{"\x1b[?1;2004h", "\x1b", "c\x1b[?25h"},
+			want:   map[int]bool{1: false, 2004: false, 25: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m modeTracker
+			for _, c := range tt.chunks {
+				m.feed([]byte(c))
+			}
+			for mode, want := range tt.want {
+				if got := m.enabled(mode); got != want {
+					t.Errorf("mode %d: got %v, want %v", mode, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestApplicationCursorKeys(t *testing.T) {
+	emu := New(6, 40).Command("sh", "-c", "printf '\\033[?1hready\\n'; stty raw -echo; head -c 3 | od -An -c")
+	t.Cleanup(func() { _ = emu.Close() })
+
+	if err := emu.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	emu.AssertScreenContains(t, "ready")
+	if !emu.ApplicationCursorKeys() {
+		t.Fatal("expected application cursor keys mode to be enabled")
+	}
+
+	if err := emu.KeyPress(keys.Up); err != nil {
+		t.Fatalf("send up: %v", err)
+	}
+
+	// od prints ESC as 033 followed by the SS3 form "O A"
+	emu.AssertScreenContains(t, "033   O   A")
+}
+
+func TestApplicationCursorKeysResetByRIS(t *testing.T) {
+	emu := New(6, 40).Command("sh", "-c", "printf '\\033[?1h'; sleep 0.2; printf '\\033cready\\n'; stty raw -echo; head -c 3 | od -An -c")
+	t.Cleanup(func() { _ = emu.Close() })
+
+	if err := emu.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	emu.AssertScreenContains(t, "ready")
+	if emu.ApplicationCursorKeys() {
+		t.Fatal("expected application cursor keys mode to be reset by RIS")
+	}
+
+	if err := emu.KeyPress(keys.Up); err != nil {
+		t.Fatalf("send up: %v", err)
+	}
+
+	// Cursor keys are back in normal mode (ESC [ A)
+	emu.AssertScreenContains(t, "033   [   A")
+}
+
+func TestAssertMode(t *testing.T) {
+	emu := New(6, 40).
+		Command("sh", "-c", "printf '\\033[?2004h\\033[?25lready\\n'; sleep 5").
+		WithAssertMaxAttempts(3).
+		WithAssertInitialDelay(10 * time.Millisecond)
+	t.Cleanup(func() { _ = emu.Close() })
+
+	if err := emu.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	emu.AssertModeEnabled(t, ModeBracketedPaste)
+	emu.AssertModeDisabled(t, ModeShowCursor)
+	emu.AssertModeDisabled(t, ModeAltScreen)
+
+	b := NewBatchAssert(t)
+	emu.AssertModeEnabled(b, ModeAltScreen)
+	failures := b.Failures()
+	if len(failures) != 1 {
+		t.Fatalf("expected AssertModeEnabled to fail once, got %d failures", len(failures))
+	}
+	if !strings.Contains(failures[0], "mode 1049 is not enabled") || !strings.Contains(failures[0], "enabled modes: [2004]") {
+		t.Errorf("unexpected failure message: %s", failures[0])
+	}
+}
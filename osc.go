@@ -0,0 +1,118 @@
+package vtermtest
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+)
+
+// OSC commands handled by the emulator.
+const (
+	oscIconAndTitle = 0 // OSC 0 ; text ST sets the icon name and window title
+	oscTitle        = 2 // OSC 2 ; text ST sets the window title
+	oscHyperlink    = 8 // OSC 8 ; params ; url ST starts a hyperlink, or ends it if url is empty
+)
+
+// maxPendingOSC bounds how much of an incomplete OSC sequence is kept between reads.
+const maxPendingOSC = 4096
+
+// oscCommand is an operating system command (ESC ] code ; text ST) from the output.
+// end is the offset just past its terminator in the data passed to feed.
+type oscCommand struct {
+	code int
+	text string
+	end  int
+}
+
+// oscTracker finds OSC sequences in the program's output, terminated by BEL or
+// ST (ESC \). Sequences split across reads are completed on the next feed.
+type oscTracker struct {
+	pending []byte
+}
+
+// feed returns the OSC commands found in data, in order.
+func (o *oscTracker) feed(data []byte) []oscCommand {
+	buf := data
+	carried := len(o.pending)
+	if carried > 0 {
+		buf = append(o.pending, data...)
+		o.pending = nil
+	}
+
+	var cmds []oscCommand
+	for i := 0; i < len(buf); i++ {
+		if buf[i] != 0x1B {
+			continue
+		}
+
+		rest := buf[i:]
+		if len(rest) < 2 {
+			o.pending = append([]byte(nil), rest...)
+			return cmds
+		}
+		if rest[1] != ']' {
+			continue
+		}
+
+		end, termLen := oscEnd(rest[2:])
+		if end < 0 {
+			if len(rest) <= maxPendingOSC {
+				o.pending = append([]byte(nil), rest...)
+			}
+			return cmds
+		}
+
+		body := rest[2 : 2+end]
+		code, text := body, []byte(nil)
+		if k := bytes.IndexByte(body, ';'); k >= 0 {
+			code, text = body[:k], body[k+1:]
+		}
+		if n, err := strconv.Atoi(string(code)); err == nil {
+			cmds = append(cmds, oscCommand{code: n, text: string(text), end: i + 2 + end + termLen - carried})
+		}
+		i += 2 + end + termLen - 1
+	}
+	return cmds
+}
+
+// oscEnd returns the length of the OSC body in b and the length of its
+// terminator (BEL or ESC \), or -1 if b holds no terminator yet.
+func oscEnd(b []byte) (int, int) {
+	for i := 0; i < len(b); i++ {
+		switch b[i] {
+		case 0x07:
+			return i, 1
+		case 0x1B:
+			if i+1 < len(b) && b[i+1] == '\\' {
+				return i, 2
+			}
+			if i+1 == len(b) {
+				return -1, 0
+			}
+		}
+	}
+	return -1, 0
+}
+
+// GetTitle returns the window title most recently set by the program with
+// OSC 0 or OSC 2 (e.g. ESC ] 0 ; title BEL), or "" if it has not set one.
+func (e *Emulator) GetTitle() (string, error) {
+	if e.ptmx == nil {
+		return "", errors.New("emulator not started")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.title, nil
+}
+
+// handleOSC applies OSC commands from the output. The caller must hold e.mu.
+func (e *Emulator) handleOSC(cmds []oscCommand) {
+	for _, cmd := range cmds {
+		switch cmd.code {
+		case oscIconAndTitle, oscTitle:
+			e.title = cmd.text
+		}
+	}
+}
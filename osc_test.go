@@ -0,0 +1,76 @@
+package vtermtest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestOSCTracker(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   []oscCommand
+	}{
+		{
+			name:   "BEL and ST terminators",
+			chunks: []string{"a\x1b]0;vim main.go\x07b\x1b]2;other\x1b\\c"},
+			want:   []oscCommand{{0, "vim main.go", 17}, {2, "other", 29}},
+		},
+		{
+			name:   "split across reads",
+			chunks: []string{"\x1b", "]2;ti", "tle\x1b", "\\"},
+			want:   []oscCommand{{2, "title", 1}},
+		},
+		{
+			name:   "no text",
+			chunks: []string{"\x1b]8;;\x07"},
+			want:   []oscCommand{{8, ";", 6}},
+		},
+		{
+			name:   "other sequences ignored",
+			chunks: []string{"\x1b[2J\x1b]x;y\x07"},
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var o oscTracker
+			var got []oscCommand
+			for _, c := range tt.chunks {
+				got = append(got, o.feed([]byte(c))...)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetTitle(t *testing.T) {
+	emu := New(4, 40).
+		Command("sh", "-c", "printf '\\033]0;zsh\\007'; read x; printf '\\033]2;vim main.go\\033\\\\done'; sleep 5")
+	t.Cleanup(func() { _ = emu.Close() })
+
+	if err := emu.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	emu.AssertScreenFunc(t, func(string) error {
+		title, err := emu.GetTitle()
+		if err == nil && title != "zsh" {
+			return fmt.Errorf("title = %q, want %q", title, "zsh")
+		}
+		return err
+	})
+
+	if err := emu.KeyPressString("<Enter>"); err != nil {
+		t.Fatalf("send enter: %v", err)
+	}
+	emu.AssertScreenContains(t, "done")
+	if title, err := emu.GetTitle(); err != nil || title != "vim main.go" {
+		t.Errorf("GetTitle() = %q, %v; want %q", title, err, "vim main.go")
+	}
+}
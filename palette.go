@@ -0,0 +1,334 @@
+package vtermtest
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+
+	libvterm "github.com/mattn/go-libvterm"
+)
+
+// maxPendingSGR bounds how much of an incomplete CSI sequence is held back between reads.
+const maxPendingSGR = 64
+
+// WithPalette sets the RGB values of the 16 ANSI colors (0-7 normal, 8-15 bright).
+// Indexed colors in the program's output (SGR 30-37, 40-47, 90-97, 100-107 and
+// 38;5;n / 48;5;n with n < 16) are rendered as these RGB values, so GetCellColors
+// reports them as such. Raw output (GetRawBytes) is not affected.
+// Returns self for method chaining.
+func (e *Emulator) WithPalette(colors [16]color.RGBA) *Emulator {
+	e.sgrRewriter().palette = &colors
+	return e
+}
+
+// WithColorResolution sets whether indexed colors in the program's output (SGR
+// 30-37, 40-47, 90-97, 100-107 and 38;5;n / 48;5;n) are rewritten to RGB using
+// xterm's default 256-color values, or the WithPalette colors for the first 16,
+// before libvterm sees them. The binding reads cell colors as RGB only, so this
+// makes GetLineColors and GetCellColors report indexed colors deterministically.
+// It is on by default. Returns self for method chaining.
+func (e *Emulator) WithColorResolution(enable bool) *Emulator {
+	e.sgrRewriter().resolveIndexed = enable
+	return e
+}
+
+// sgrRewriter returns the emulator's SGR rewriter, creating it if needed.
+func (e *Emulator) sgrRewriter() *sgrRewriter {
+	if e.sgr == nil {
+		e.sgr = &sgrRewriter{}
+	}
+	return e.sgr
+}
+
+// GetCellColors returns the foreground and background colors of a cell.
+// Row and column are 0-based. Colors set with indexed SGR codes are resolved to
+// RGB using the palette configured with WithPalette, or xterm's default colors
+// unless WithColorResolution is disabled.
+func (e *Emulator) GetCellColors(row, col int) (fg, bg color.RGBA, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.screen == nil {
+		return fg, bg, fmt.Errorf("emulator not started")
+	}
+	if row < 0 || row >= int(e.rows) || col < 0 || col >= int(e.cols) {
+		return fg, bg, fmt.Errorf("cell (%d, %d) out of range for %dx%d screen", row, col, e.rows, e.cols)
+	}
+
+	cell, err := e.screen.GetCell(libvterm.NewPos(row, col))
+	if err != nil {
+		return fg, bg, err
+	}
+	return color.RGBAModel.Convert(cell.Fg()).(color.RGBA), color.RGBAModel.Convert(cell.Bg()).(color.RGBA), nil
+}
+
+// sgrRewriter replaces indexed ANSI colors in SGR sequences with truecolor ones
+// from its palette, if set, and all others with xterm's defaults if resolveIndexed
+// is set. It marks concealed text with concealFont, if concealAsFont is set.
+// With resolveIndexed set it also marks text whose foreground the program set
+// explicitly to libvterm's default color with explicitFgFont, since the binding
+// reports a default foreground as that color too.
+// Sequences split across reads are held back until complete.
+type sgrRewriter struct {
+	palette        *[16]color.RGBA
+	resolveIndexed bool
+	concealAsFont  bool
+	pending        []byte
+
+	// Pen state: the foreground is explicitly defaultForeground, conceal is on,
+	// and explicitFgFont is selected
+	explicitFg bool
+	concealed  bool
+	fgMarked   bool
+}
+
+// explicitFgFont is the alternate font (SGR 10+n) that marks text whose
+// foreground was set explicitly to defaultForeground.
+const explicitFgFont = 9
+
+// feed returns data with SGR colors rewritten, minus any trailing incomplete sequence.
+func (r *sgrRewriter) feed(data []byte) []byte {
+	buf := data
+	if len(r.pending) > 0 {
+		buf = append(r.pending, data...)
+		r.pending = nil
+	}
+
+	out := make([]byte, 0, len(buf))
+	for i := 0; i < len(buf); i++ {
+		if buf[i] != 0x1B {
+			out = append(out, buf[i])
+			continue
+		}
+
+		rest := buf[i:]
+		if len(rest) < 2 {
+			r.pending = append([]byte(nil), rest...)
+			return out
+		}
+		if rest[1] != '[' {
+			out = append(out, buf[i])
+			continue
+		}
+
+		j := 2
+		for j < len(rest) && (rest[j] == ';' || rest[j] == ':' || (rest[j] >= '0' && rest[j] <= '9')) {
+			j++
+		}
+		if j == len(rest) && len(rest) <= maxPendingSGR {
+			r.pending = append([]byte(nil), rest...)
+			return out
+		}
+		if j < len(rest) && rest[j] == 'm' {
+			out = append(out, "\x1b["+r.rewrite(string(rest[2:j]))+"m"...)
+			i += j
+			continue
+		}
+		out = append(out, buf[i])
+	}
+	return out
+}
+
+// flush returns the trailing incomplete sequence held back by feed, if any, so it
+// can be passed on unchanged once no more data will follow.
+func (r *sgrRewriter) flush() []byte {
+	rest := r.pending
+	r.pending = nil
+	return rest
+}
+
+// rewrite maps the indexed colors among SGR params to truecolor params, conceal
+// on and off to selecting and leaving the alternate font marking it, and marks
+// an explicit default-colored foreground.
+func (r *sgrRewriter) rewrite(params string) string {
+	if strings.Contains(params, ":") {
+		return params
+	}
+
+	in := strings.Split(params, ";")
+	out := make([]string, 0, len(in))
+	for i := 0; i < len(in); i++ {
+		n, err := strconv.Atoi(in[i])
+		if err != nil {
+			if in[i] == "" {
+				r.resetPen() // an empty parameter is SGR 0
+			}
+			out = append(out, in[i])
+			continue
+		}
+
+		switch {
+		case n == 0:
+			r.resetPen()
+			out = append(out, in[i])
+		case n == 39:
+			r.explicitFg = false
+			out = append(out, in[i])
+		case n >= 10 && n <= 19:
+			r.fgMarked = false // the program selected a font
+			out = append(out, in[i])
+		case (n == 38 || n == 48) && i+2 < len(in) && in[i+1] == "5":
+			if idx, err := strconv.Atoi(in[i+2]); err == nil && r.resolves(idx) {
+				if n == 38 {
+					r.explicitFg = r.color(idx) == defaultForeground
+				}
+				out = append(out, r.truecolor(n, idx))
+			} else {
+				if n == 38 {
+					r.explicitFg = false
+				}
+				out = append(out, in[i:i+3]...)
+			}
+			i += 2
+		case (n == 38 || n == 48) && i+4 < len(in) && in[i+1] == "2":
+			if n == 38 {
+				r.explicitFg = isDefaultForeground(in[i+2 : i+5])
+			}
+			out = append(out, in[i:i+5]...)
+			i += 4
+		case r.concealAsFont && n == 8:
+			r.concealed = true
+			r.fgMarked = false
+			out = append(out, strconv.Itoa(10+concealFont))
+		case r.concealAsFont && n == 28:
+			r.concealed = false
+			r.fgMarked = false
+			out = append(out, "10")
+		case r.palette == nil && !r.resolveIndexed:
+			out = append(out, in[i])
+		case n >= 30 && n <= 37:
+			r.explicitFg = r.color(n-30) == defaultForeground
+			out = append(out, r.truecolor(38, n-30))
+		case n >= 90 && n <= 97:
+			r.explicitFg = r.color(n-90+8) == defaultForeground
+			out = append(out, r.truecolor(38, n-90+8))
+		case n >= 40 && n <= 47:
+			out = append(out, r.truecolor(48, n-40))
+		case n >= 100 && n <= 107:
+			out = append(out, r.truecolor(48, n-100+8))
+		default:
+			out = append(out, in[i])
+		}
+	}
+
+	// Concealed text keeps the conceal font
+	want := r.resolveIndexed && r.explicitFg && !(r.concealAsFont && r.concealed)
+	switch {
+	case want && !r.fgMarked:
+		out = append(out, strconv.Itoa(10+explicitFgFont))
+	case !want && r.fgMarked:
+		out = append(out, "10")
+	}
+	r.fgMarked = want
+	return strings.Join(out, ";")
+}
+
+// resetPen records an SGR 0, which also selects the primary font.
+func (r *sgrRewriter) resetPen() {
+	r.explicitFg = false
+	r.concealed = false
+	r.fgMarked = false
+}
+
+// isDefaultForeground reports whether truecolor params r;g;b are defaultForeground.
+func isDefaultForeground(rgb []string) bool {
+	want := [3]uint8{defaultForeground.R, defaultForeground.G, defaultForeground.B}
+	for i, p := range rgb {
+		if v, err := strconv.Atoi(p); err != nil || v != int(want[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// resolves reports whether the color index is rewritten to truecolor.
+func (r *sgrRewriter) resolves(index int) bool {
+	if index < 0 || index > 255 {
+		return false
+	}
+	return r.resolveIndexed || (index < 16 && r.palette != nil)
+}
+
+func (r *sgrRewriter) truecolor(code, index int) string {
+	c := r.color(index)
+	return fmt.Sprintf("%d;2;%d;%d;%d", code, c.R, c.G, c.B)
+}
+
+// color returns the RGB value of a palette index.
+func (r *sgrRewriter) color(index int) color.RGBA {
+	if index < 16 && r.palette != nil {
+		return r.palette[index]
+	}
+	return xtermColor(index)
+}
+
+// xtermANSI holds xterm's default values for the 16 ANSI colors.
+var xtermANSI = [16]color.RGBA{
+	{0x00, 0x00, 0x00, 0xFF}, {0xCD, 0x00, 0x00, 0xFF}, {0x00, 0xCD, 0x00, 0xFF}, {0xCD, 0xCD, 0x00, 0xFF},
+	{0x00, 0x00, 0xEE, 0xFF}, {0xCD, 0x00, 0xCD, 0xFF}, {0x00, 0xCD, 0xCD, 0xFF}, {0xE5, 0xE5, 0xE5, 0xFF},
+	{0x7F, 0x7F, 0x7F, 0xFF}, {0xFF, 0x00, 0x00, 0xFF}, {0x00, 0xFF, 0x00, 0xFF}, {0xFF, 0xFF, 0x00, 0xFF},
+	{0x5C, 0x5C, 0xFF, 0xFF}, {0xFF, 0x00, 0xFF, 0xFF}, {0x00, 0xFF, 0xFF, 0xFF}, {0xFF, 0xFF, 0xFF, 0xFF},
+}
+
+// xtermColor returns xterm's default RGB value for a 256-color palette index:
+// the 16 ANSI colors, a 6x6x6 color cube, then a 24-step grayscale ramp.
+func xtermColor(index int) color.RGBA {
+	switch {
+	case index < 16:
+		return xtermANSI[index]
+	case index < 232:
+		ramp := [6]uint8{0x00, 0x5F, 0x87, 0xAF, 0xD7, 0xFF}
+		i := index - 16
+		return color.RGBA{ramp[i/36], ramp[i/6%6], ramp[i%6], 0xFF}
+	default:
+		v := uint8(8 + 10*(index-232))
+		return color.RGBA{v, v, v, 0xFF}
+	}
+}
+
+// defaultForeground is libvterm's default foreground color, reported for cells
+// whose foreground the program has not set.
+var defaultForeground = color.RGBA{R: 240, G: 240, B: 240, A: 255}
+
+// defaultBackground is libvterm's default background color.
+var defaultBackground = color.RGBA{A: 255}
+
+// CellColor is the foreground color of a cell.
+type CellColor struct {
+	RGB color.RGBA
+	// Default is true if the program left the foreground at the terminal's
+	// default. An explicit #F0F0F0 (libvterm's default) is only told apart
+	// while color resolution is on.
+	Default bool
+}
+
+// GetLineColors returns the foreground color of each column of a line (0-based).
+// Indexed colors (SGR 30-37, 90-97 and 38;5;n) are resolved to RGB using the
+// palette configured with WithPalette or xterm's default colors, unless
+// WithColorResolution is disabled, so results do not depend on the host terminal.
+// The second column of a wide character has the character's color.
+func (e *Emulator) GetLineColors(row int) ([]CellColor, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.screen == nil {
+		return nil, fmt.Errorf("emulator not started")
+	}
+	if row < 0 || row >= int(e.rows) {
+		return nil, fmt.Errorf("row %d out of range for %d rows", row, e.rows)
+	}
+
+	colors := make([]CellColor, e.cols)
+	for col := range colors {
+		cell, err := e.screen.GetCell(libvterm.NewPos(row, col))
+		if err != nil {
+			return nil, err
+		}
+		// The rewriter marks an explicit default-colored foreground with a font
+		fg := color.RGBAModel.Convert(cell.Fg()).(color.RGBA)
+		explicit := cell.Attrs().Font == explicitFgFont
+		colors[col] = CellColor{RGB: fg, Default: fg == defaultForeground && !explicit}
+	}
+	return colors, nil
+}
@@ -0,0 +1,163 @@
+package vtermtest
+
+import (
+	"context"
+	"image/color"
+	"testing"
+)
+
+func testPalette() [16]color.RGBA {
+	var p [16]color.RGBA
+	for i := range p {
+		p[i] = color.RGBA{R: uint8(i), G: 100, B: 200, A: 255}
+	}
+	p[4] = color.RGBA{R: 0x00, G: 0x66, B: 0xCC, A: 255}
+	return p
+}
+
+func TestSGRRewriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   string
+	}{
+		{
+			name:   "basic colors",
+			chunks: []string{"\x1b[34mblue\x1b[0m"},
+			want:   "\x1b[38;2;0;102;204mblue\x1b[0m",
+		},
+		{
+			name:   "bright and background",
+			chunks: []string{"\x1b[1;91;44m"},
+			want:   "\x1b[1;38;2;9;100;200;48;2;0;102;204m",
+		},
+		{
+			name:   "256 colors",
+			chunks: []string{"\x1b[38;5;4;48;5;200m"},
+			want:   "\x1b[38;2;0;102;204;48;5;200m",
+		},
+		{
+			name:   "truecolor and colon forms kept",
+			chunks: []string{"\x1b[38;2;1;2;3;31m\x1b[38:5:4m"},
+			want:   "\x1b[38;2;1;2;3;38;2;1;100;200m\x1b[38:5:4m",
+		},
+		{
+			name:   "other sequences kept",
+			chunks: []string{"\x1b[2J\x1b[?25l\x1bc"},
+			want:   "\x1b[2J\x1b[?25l\x1bc",
+		},
+		{
+			name:   "split across reads",
+			chunks: []string{"a\x1b", "[3", "4mb"},
+			want:   "a\x1b[38;2;0;102;204mb",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := testPalette()
+			r := &sgrRewriter{palette: &p}
+			var got []byte
+			for _, c := range tt.chunks {
+				got = append(got, r.feed([]byte(c))...)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetCellColors(t *testing.T) {
+	emu := New(4, 40).
+		Command("printf", "\\033[34;41mX\\033[0m").
+		WithPalette(testPalette())
+	t.Cleanup(func() { _ = emu.Close() })
+
+	if err := emu.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	emu.AssertLineEqual(t, 0, "X")
+
+	fg, bg, err := emu.GetCellColors(0, 0)
+	if err != nil {
+		t.Fatalf("GetCellColors: %v", err)
+	}
+	if want := (color.RGBA{R: 0x00, G: 0x66, B: 0xCC, A: 255}); fg != want {
+		t.Errorf("fg = %v, want %v", fg, want)
+	}
+	if want := (color.RGBA{R: 1, G: 100, B: 200, A: 255}); bg != want {
+		t.Errorf("bg = %v, want %v", bg, want)
+	}
+
+	if _, _, err := emu.GetCellColors(4, 0); err == nil {
+		t.Error("expected an error for a cell outside the screen")
+	}
+}
+
+func TestSGRRewriterResolveIndexed(t *testing.T) {
+	r := &sgrRewriter{resolveIndexed: true}
+	got := string(r.feed([]byte("\x1b[31;38;5;196;48;5;244m\x1b[38;2;1;2;3m\x1b[39m")))
+	want := "\x1b[38;2;205;0;0;38;2;255;0;0;48;2;128;128;128m\x1b[38;2;1;2;3m\x1b[39m"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSGRRewriterExplicitDefaultForeground(t *testing.T) {
+	r := &sgrRewriter{resolveIndexed: true, concealAsFont: true}
+	got := string(r.feed([]byte("\x1b[38;2;240;240;240m\x1b[1m\x1b[8m\x1b[28m\x1b[39m\x1b[38;2;240;240;240m\x1b[0m")))
+	want := "\x1b[38;2;240;240;240;19m\x1b[1m\x1b[18m\x1b[10;19m\x1b[39;10m\x1b[38;2;240;240;240;19m\x1b[0m"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSGRRewriterFlush(t *testing.T) {
+	r := &sgrRewriter{resolveIndexed: true}
+	if got := string(r.feed([]byte("a\x1b[3"))); got != "a" {
+		t.Errorf("feed: got %q, want %q", got, "a")
+	}
+	if got := string(r.flush()); got != "\x1b[3" {
+		t.Errorf("flush: got %q, want %q", got, "\x1b[3")
+	}
+	if got := r.flush(); len(got) != 0 {
+		t.Errorf("second flush: got %q, want nothing", got)
+	}
+}
+
+func TestGetLineColors(t *testing.T) {
+	emu := New(4, 10).
+		Command("printf", "a\\033[34mb\\033[38;5;208mc\\033[38;2;1;2;3md\\033[39me\\033[38;2;240;240;240mf")
+	t.Cleanup(func() { _ = emu.Close() })
+
+	if err := emu.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	emu.AssertLineEqual(t, 0, "abcdef")
+
+	colors, err := emu.GetLineColors(0)
+	if err != nil {
+		t.Fatalf("GetLineColors: %v", err)
+	}
+	if len(colors) != 10 {
+		t.Fatalf("got %d colors, want 10", len(colors))
+	}
+	want := []CellColor{
+		{RGB: defaultForeground, Default: true},
+		{RGB: color.RGBA{R: 0x00, G: 0x00, B: 0xEE, A: 255}},
+		{RGB: color.RGBA{R: 0xFF, G: 0x87, B: 0x00, A: 255}},
+		{RGB: color.RGBA{R: 1, G: 2, B: 3, A: 255}},
+		{RGB: defaultForeground, Default: true},
+		{RGB: defaultForeground},
+	}
+	for i, w := range want {
+		if colors[i] != w {
+			t.Errorf("col %d: got %+v, want %+v", i, colors[i], w)
+		}
+	}
+
+	if _, err := emu.GetLineColors(4); err == nil {
+		t.Error("expected an error for a row outside the screen")
+	}
+}
@@ -0,0 +1,52 @@
+package vtermtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// SignalError is returned by Wait when the program was terminated by a signal
+// instead of exiting normally.
+type SignalError struct {
+	Signal os.Signal
+}
+
+func (err *SignalError) Error() string {
+	return fmt.Sprintf("program terminated by signal: %v", err.Signal)
+}
+
+// waitProcess reaps the program once it exits and records the result for Wait
+// and Close.
+func (e *Emulator) waitProcess() {
+	e.waitErr = e.cmd.Wait()
+	close(e.processDone)
+}
+
+// Wait waits for the program to exit and returns its exit code, e.g. to check that
+// a CLI exits nonzero on bad input. It can be called any number of times, before
+// or after Close. If the program was terminated by a signal (including by Close),
+// the code is -1 and the error is a *SignalError. If ctx is done first, -1 and
+// ctx.Err() are returned and the program keeps running.
+func (e *Emulator) Wait(ctx context.Context) (int, error) {
+	if e.processDone == nil {
+		return -1, errors.New("emulator not started")
+	}
+
+	select {
+	case <-e.processDone:
+	case <-ctx.Done():
+		return -1, ctx.Err()
+	}
+
+	var exitErr *exec.ExitError
+	if e.waitErr != nil && !errors.As(e.waitErr, &exitErr) {
+		return -1, e.waitErr
+	}
+	if sig, ok := exitSignal(e.cmd.ProcessState); ok {
+		return -1, &SignalError{Signal: sig}
+	}
+	return e.cmd.ProcessState.ExitCode(), nil
+}
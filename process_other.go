@@ -0,0 +1,27 @@
+//go:build !unix
+// +build !unix
+
+package vtermtest
+
+import (
+	"os"
+	"os/exec"
+)
+
+// checkSysProcAttr accepts any command; the PTY is not attached with Setsid on
+// this platform.
+func checkSysProcAttr(cmd *exec.Cmd) error {
+	return nil
+}
+
+// killProcessGroup kills only the process itself; process groups are not
+// available on this platform.
+func killProcessGroup(p *os.Process) error {
+	return p.Kill()
+}
+
+// exitSignal reports no signal; termination by signal cannot be told apart
+// from an exit code on this platform.
+func exitSignal(ps *os.ProcessState) (os.Signal, bool) {
+	return nil, false
+}
@@ -0,0 +1,42 @@
+//go:build unix
+// +build unix
+
+package vtermtest
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// checkSysProcAttr rejects settings of a caller-supplied command that conflict
+// with the Setsid the PTY is attached with.
+func checkSysProcAttr(cmd *exec.Cmd) error {
+	if attr := cmd.SysProcAttr; attr != nil && attr.Setpgid {
+		return errors.New("WithCmd: SysProcAttr.Setpgid conflicts with the Setsid used to attach the PTY")
+	}
+	return nil
+}
+
+// killProcessGroup kills the process and all of its descendants.
+// The PTY is attached with Setsid, so the process leads a new session and
+// process group (its pgid is its pid); a separate Setpgid is neither needed
+// nor allowed for a session leader. Descendants that start their own session
+// or process group are not reached.
+func killProcessGroup(p *os.Process) error {
+	err := syscall.Kill(-p.Pid, syscall.SIGKILL)
+	if errors.Is(err, syscall.ESRCH) {
+		return os.ErrProcessDone
+	}
+	return err
+}
+
+// exitSignal returns the signal that terminated the process, if any.
+func exitSignal(ps *os.ProcessState) (os.Signal, bool) {
+	status, ok := ps.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return nil, false
+	}
+	return status.Signal(), true
+}
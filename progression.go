@@ -0,0 +1,55 @@
+package vtermtest
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/c-bata/vtermtest/keys"
+)
+
+// Frame is a screen captured after one step of input.
+type Frame struct {
+	// Token is the input that led to this screen, quoted with %q
+	// (e.g. "hello", "\t", "\x1b[A").
+	Token string
+	// Screen is the screen text once it settled.
+	Screen string
+}
+
+// CaptureProgression sends dsl one token at a time and records the screen after
+// each token has settled (no output for settleEach), returning the frames in
+// order. Text between tags is one token; each tag is another. Directives such as
+// <WaitFor> and <Label> are performed but do not produce frames. The result is a
+// step-by-step fixture of how the program reacts to input, suitable for saving
+// as a regression baseline.
+func (e *Emulator) CaptureProgression(dsl string, settleEach time.Duration) ([]Frame, error) {
+	parsedKeys, err := e.parseDSL(dsl, keys.DefaultParseOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	var frames []Frame
+	label := ""
+	for i, key := range parsedKeys {
+		sent, err := e.runDSLStep(key, &label)
+		if err == nil && sent && !e.WaitStable(settleEach, settleEach+e.getWaitTimeout()) {
+			err = fmt.Errorf("screen did not stabilize after %q", key)
+		}
+		if err != nil {
+			if label != "" {
+				return frames, fmt.Errorf("step %q: %w", label, err)
+			}
+			return frames, fmt.Errorf("token %d: %w", i, err)
+		}
+		if !sent {
+			continue
+		}
+
+		screen, err := e.GetScreenText()
+		if err != nil {
+			return frames, fmt.Errorf("failed to get screen text: %w", err)
+		}
+		frames = append(frames, Frame{Token: fmt.Sprintf("%q", key), Screen: screen})
+	}
+	return frames, nil
+}
@@ -0,0 +1,52 @@
+package vtermtest
+
+import (
+	"regexp"
+	"strings"
+)
+
+// WithPromptPrefix sets a REPL prompt (e.g. ">>> ") that GetLineStrippingPrompt
+// removes from the start of lines. The prefix must match exactly, including spaces.
+// Returns self for method chaining.
+func (e *Emulator) WithPromptPrefix(p string) *Emulator {
+	e.promptPrefix = p
+	e.promptPattern = nil
+	return e
+}
+
+// WithPromptRegexp sets a REPL prompt pattern for prompts that vary, such as
+// "In [3]: ". The pattern is matched at the start of the line only; the leftmost
+// match is removed by GetLineStrippingPrompt. Returns self for method chaining.
+func (e *Emulator) WithPromptRegexp(re *regexp.Regexp) *Emulator {
+	e.promptPattern = re
+	e.promptPrefix = ""
+	return e
+}
+
+// GetLineStrippingPrompt returns a line like GetLine with the configured prompt
+// removed from its start. Lines that do not start with the prompt, or any line
+// when no prompt is configured, are returned unchanged.
+func (e *Emulator) GetLineStrippingPrompt(row int) (string, error) {
+	line, err := e.GetLine(row)
+	if err != nil {
+		return "", err
+	}
+	return e.stripPrompt(line), nil
+}
+
+func (e *Emulator) stripPrompt(line string) string {
+	if e.promptPattern != nil {
+		if loc := e.promptPattern.FindStringIndex(line); loc != nil && loc[0] == 0 {
+			return line[loc[1]:]
+		}
+		return line
+	}
+	if e.promptPrefix != "" {
+		// Lines are right-trimmed, so a prompt alone on a line loses its trailing spaces
+		if line == strings.TrimRight(e.promptPrefix, " ") {
+			return ""
+		}
+		return strings.TrimPrefix(line, e.promptPrefix)
+	}
+	return line
+}
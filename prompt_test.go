@@ -0,0 +1,30 @@
+package vtermtest
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestStripPrompt(t *testing.T) {
+	tests := []struct {
+		name string
+		emu  *Emulator
+		line string
+		want string
+	}{
+		{"no prompt configured", New(1, 1), ">>> select", ">>> select"},
+		{"exact prefix", New(1, 1).WithPromptPrefix(">>> "), ">>> select", "select"},
+		{"prompt only", New(1, 1).WithPromptPrefix(">>> "), ">>>", ""},
+		{"no match", New(1, 1).WithPromptPrefix(">>> "), "output", "output"},
+		{"regexp", New(1, 1).WithPromptRegexp(regexp.MustCompile(`In \[\d+\]: `)), "In [12]: x = 1", "x = 1"},
+		{"regexp not at start", New(1, 1).WithPromptRegexp(regexp.MustCompile(`In \[\d+\]: `)), "x In [1]: y", "x In [1]: y"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.emu.stripPrompt(tt.line); got != tt.want {
+				t.Errorf("stripPrompt(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package vtermtest
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/c-bata/vtermtest/asciicast"
+)
+
+// rawChunk is a chunk of PTY output with its offset from Start.
+type rawChunk struct {
+	at   time.Duration
+	data []byte
+}
+
+// ExportAsciicast writes the program's output so far as an asciicast v2 recording,
+// playable with asciinema. Raw bytes collection must be enabled with
+// EnableRawBytesCollection() before Start so output chunks are timestamped.
+func (e *Emulator) ExportAsciicast(w io.Writer) error {
+	e.mu.Lock()
+	if !e.collectRawBytes {
+		e.mu.Unlock()
+		return errors.New("raw bytes collection is not enabled")
+	}
+	chunks := make([]rawChunk, len(e.rawChunks))
+	copy(chunks, e.rawChunks)
+	header := asciicast.Header{
+		Width:     int(e.cols),
+		Height:    int(e.rows),
+		Timestamp: e.startTime.Unix(),
+		Env:       e.asciicastEnv(),
+	}
+	e.mu.Unlock()
+
+	enc := asciicast.NewEncoder(w)
+	if err := enc.WriteHeader(header); err != nil {
+		return err
+	}
+	for _, chunk := range chunks {
+		if err := enc.WriteOutput(chunk.at, chunk.data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// asciicastEnv returns the TERM and SHELL variables configured with Env.
+func (e *Emulator) asciicastEnv() map[string]string {
+	env := make(map[string]string)
+	for _, kv := range e.env {
+		for _, key := range []string{"TERM", "SHELL"} {
+			if len(kv) > len(key) && kv[:len(key)+1] == key+"=" {
+				env[key] = kv[len(key)+1:]
+			}
+		}
+	}
+	if len(env) == 0 {
+		return nil
+	}
+	return env
+}
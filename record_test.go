@@ -0,0 +1,60 @@
+package vtermtest_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/c-bata/vtermtest"
+)
+
+func TestExportAsciicast(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(6, 40).
+		Command("sh", "-c", "echo 'first'; sleep 0.1; echo 'second'").
+		Env("LANG=C.UTF-8", "TERM=xterm").
+		EnableRawBytesCollection()
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	emu.AssertScreenContains(t, "second")
+
+	var buf bytes.Buffer
+	if err := emu.ExportAsciicast(&buf); err != nil {
+		t.Fatalf("ExportAsciicast failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var header map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &header); err != nil {
+		t.Fatalf("invalid header %q: %v", lines[0], err)
+	}
+	if header["version"] != float64(2) || header["width"] != float64(40) || header["height"] != float64(6) {
+		t.Errorf("unexpected header: %v", header)
+	}
+
+	var output strings.Builder
+	for _, line := range lines[1:] {
+		var event []interface{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("invalid event %q: %v", line, err)
+		}
+		if len(event) != 3 || event[1] != "o" {
+			t.Fatalf("unexpected event: %v", event)
+		}
+		output.WriteString(event[2].(string))
+	}
+	if !strings.Contains(output.String(), "first") || !strings.Contains(output.String(), "second") {
+		t.Errorf("events should contain the program output, got %q", output.String())
+	}
+
+	if err := vtermtest.New(1, 1).ExportAsciicast(&buf); err == nil {
+		t.Error("expected an error when raw bytes collection is disabled")
+	}
+}
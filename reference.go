@@ -0,0 +1,146 @@
+package vtermtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Skipper is implemented by testing.T; CompareWithCommand uses it to skip the
+// test when the reference terminal is not installed.
+type Skipper interface {
+	Skipf(format string, args ...interface{})
+}
+
+// CompareWithCommand renders the program's output so far in a reference terminal
+// and asserts that it matches the emulator's screen, to catch rendering
+// differences between libvterm and a real terminal. The only supported reference
+// is "tmux": the raw output is replayed in a detached tmux session of the same size
+// and read back with capture-pane. Raw bytes collection must be enabled with
+// EnableRawBytesCollection(). If the reference is not installed, the test is
+// skipped when t supports Skipf, and the comparison is silently omitted otherwise.
+func (e *Emulator) CompareWithCommand(t TestingT, reference string) {
+	t.Helper()
+
+	if reference != "tmux" {
+		t.Fatalf("unsupported reference terminal %q", reference)
+		return
+	}
+	if _, err := exec.LookPath(reference); err != nil {
+		if s, ok := t.(Skipper); ok {
+			s.Skipf("reference terminal %s not available", reference)
+		}
+		return
+	}
+
+	e.mu.Lock()
+	collecting := e.collectRawBytes
+	rows, cols := e.rows, e.cols
+	e.mu.Unlock()
+	if !collecting {
+		t.Fatalf("raw bytes collection is not enabled")
+		return
+	}
+
+	want, err := renderWithTmux(e.GetRawBytes(), rows, cols)
+	if err != nil {
+		t.Fatalf("failed to render with tmux: %v", err)
+		return
+	}
+	got, err := e.getScreenText()
+	if err != nil {
+		t.Fatalf("failed to get screen: %v", err)
+		return
+	}
+
+	if got != want {
+		t.Fatalf("screen differs from %s:\n--- %s ---\n%s\n--- vtermtest ---\n%s\n%s", reference, reference, want, got, firstMismatch(want, got))
+	}
+}
+
+// renderWithTmux replays output in a private tmux server and returns the
+// resulting screen with trailing spaces trimmed, as GetScreenText does.
+func renderWithTmux(output []byte, rows, cols uint16) (string, error) {
+	replay, err := writeTempFile("vtermtest-reference-*", output)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(replay)
+
+	// The status line must be off before the pane starts, or the replay would
+	// run in a pane one row shorter than the emulator's screen
+	conf, err := writeTempFile("vtermtest-tmux-*.conf", []byte("set-option -g status off\n"))
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(conf)
+
+	socket := fmt.Sprintf("vtermtest-%d-%d", os.Getpid(), time.Now().UnixNano())
+	tmux := func(args ...string) ([]byte, error) {
+		return exec.Command("tmux", append([]string{"-L", socket, "-f", conf}, args...)...).Output()
+	}
+	defer tmux("kill-server")
+
+	// stty -onlcr keeps line endings as recorded; cat's exit would close the pane.
+	// The wait-for channel is signalled once all output has been written.
+	script := "stty -onlcr; cat " + strconv.Quote(replay) + "; tmux wait-for -S replayed; exec sleep 60"
+	if _, err := tmux("new-session", "-d", "-x", strconv.Itoa(int(cols)), "-y", strconv.Itoa(int(rows)), script); err != nil {
+		return "", fmt.Errorf("new-session: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := exec.CommandContext(ctx, "tmux", "-L", socket, "wait-for", "replayed").Run(); err != nil {
+		return "", fmt.Errorf("wait-for: %w", err)
+	}
+
+	// Wait until tmux has rendered all of it
+	deadline := time.Now().Add(5 * time.Second)
+	var last string
+	for {
+		out, err := tmux("capture-pane", "-p")
+		if err != nil {
+			return "", fmt.Errorf("capture-pane: %w", err)
+		}
+		if string(out) == last {
+			break
+		}
+		last = string(out)
+		if time.Now().After(deadline) {
+			return "", errors.New("tmux output did not settle")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(last, "\n"), "\n")
+	for len(lines) < int(rows) {
+		lines = append(lines, "")
+	}
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines[:rows], "\n"), nil
+}
+
+// writeTempFile writes data to a new temporary file and returns its name.
+func writeTempFile(pattern string, data []byte) (string, error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,35 @@
+package vtermtest
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+)
+
+func TestRenderWithTmux(t *testing.T) {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		t.Skip("tmux not available")
+	}
+
+	got, err := renderWithTmux([]byte("hello\r\n\x1b[31mred\x1b[0m\r\n\x1b[4;5Hxy"), 4, 20)
+	if err != nil {
+		t.Fatalf("renderWithTmux: %v", err)
+	}
+	if want := "hello\nred\n\n    xy"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCompareWithCommand(t *testing.T) {
+	emu := New(6, 30).
+		Command("sh", "-c", "printf 'a\\tb\\n\\033[1mbold\\033[0m\\n\\033[5;10Hmoved\\033[2;2Hx'; sleep 5").
+		EnableRawBytesCollection()
+	t.Cleanup(func() { _ = emu.Close() })
+
+	if err := emu.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	emu.AssertLineEqual(t, 1, "bxld")
+
+	emu.CompareWithCommand(t, "tmux")
+}
@@ -70,6 +70,35 @@ func TestResize(t *testing.T) {
 	}
 }
 
+// TestResizeInvalidatesScreenCache tests that the cached screen text reflects a
+// resize even when the program writes nothing afterwards
+func TestResizeInvalidatesScreenCache(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(3, 20).
+		Command("sh", "-c", "trap '' WINCH; echo hello; sleep 5").
+		Env("LANG=C.UTF-8")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	if err := emu.WaitFor("hello", 2*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if screen, _ := emu.GetScreenText(); strings.Count(screen, "\n") != 2 {
+		t.Fatalf("expected 3 rows, got %q", screen)
+	}
+
+	if err := emu.Resize(5, 20); err != nil {
+		t.Fatalf("failed to resize: %v", err)
+	}
+	if screen, _ := emu.GetScreenText(); strings.Count(screen, "\n") != 4 {
+		t.Errorf("expected 5 rows right after resizing, got %q", screen)
+	}
+}
+
 func TestResizeInteractive(t *testing.T) {
 	ctx := context.Background()
 
@@ -100,4 +129,82 @@ func TestResizeInteractive(t *testing.T) {
 	}
 
 	emu.AssertScreenContains(t, "After resize")
-}
\ No newline at end of file
+}
+
+func TestResizeSequence(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(10, 40).
+		Command("sh").
+		Env("LANG=C.UTF-8")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	sizes := [][2]uint16{{5, 20}, {20, 80}, {8, 30}, {10, 40}}
+	if err := emu.ResizeSequence(sizes, 50*time.Millisecond); err != nil {
+		t.Fatalf("failed to apply resize sequence: %v", err)
+	}
+
+	// The program should still be responsive after the sequence
+	if err := emu.KeyPress(keys.Text("echo 'still alive'"), keys.Enter); err != nil {
+		t.Fatal(err)
+	}
+
+	emu.AssertScreenContains(t, "still alive")
+}
+
+func TestResizeGradually(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(10, 40).
+		Command("sh").
+		Env("LANG=C.UTF-8")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	if err := emu.ResizeGradually([2]uint16{10, 40}, [2]uint16{20, 75}, 5, 10*time.Millisecond); err != nil {
+		t.Fatalf("failed to resize gradually: %v", err)
+	}
+
+	// The final size is exactly the target size
+	if err := emu.KeyPress(keys.Text("stty size"), keys.Enter); err != nil {
+		t.Fatal(err)
+	}
+	emu.AssertScreenContains(t, "20 75")
+
+	if err := emu.ResizeGradually([2]uint16{20, 75}, [2]uint16{10, 40}, 0, 0); err == nil {
+		t.Error("expected an error for zero steps")
+	}
+}
+
+func TestAssertMaxLineWidth(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(5, 40).
+		Command("sh", "-c", "echo '1234567890'; echo '日本語日本語'").
+		Env("LANG=C.UTF-8")
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	emu.AssertScreenContains(t, "日本語日本語")
+	emu.AssertMaxLineWidth(t, 12)
+
+	// The wide line is 12 cells even though it has 6 runes
+	mockT := &mockTest{}
+	emu.WithAssertMaxAttempts(1).AssertMaxLineWidth(mockT, 10)
+	if !mockT.failed {
+		t.Error("AssertMaxLineWidth should have failed")
+	}
+	if !strings.Contains(mockT.message, "line 1 is 12 cells wide") {
+		t.Errorf("Error message should report the offending row, got: %s", mockT.message)
+	}
+}
@@ -0,0 +1,39 @@
+package vtermtest
+
+// risTracker counts full resets (RIS, ESC c) in the program's output.
+// A reset split across reads is completed on the next feed.
+type risTracker struct {
+	pendingEsc bool
+}
+
+// feed returns the number of resets found in data.
+func (r *risTracker) feed(data []byte) int {
+	count := 0
+	if r.pendingEsc && len(data) > 0 && data[0] == 'c' {
+		count++
+	}
+	for i := 0; i+1 < len(data); i++ {
+		if data[i] == 0x1B && data[i+1] == 'c' {
+			count++
+		}
+	}
+	r.pendingEsc = len(data) > 0 && data[len(data)-1] == 0x1B
+	return count
+}
+
+// ResetCount returns how many times the program sent a full terminal reset
+// (RIS, ESC c) since Start. Resets made with Emulator.Reset are not counted.
+func (e *Emulator) ResetCount() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.resetCount
+}
+
+// OnTerminalReset registers fn to be called each time the program sends a full
+// terminal reset (RIS, ESC c). fn runs on the reader goroutine after the output
+// has been rendered, so it must not block. Returns self for method chaining.
+func (e *Emulator) OnTerminalReset(fn func()) *Emulator {
+	e.onTerminalReset = fn
+	return e
+}
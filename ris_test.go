@@ -0,0 +1,61 @@
+package vtermtest
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRISTracker(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   int
+	}{
+		{name: "none", chunks: []string{"abc\x1b[2Jc"}, want: 0},
+		{name: "single", chunks: []string{"bye\x1bc"}, want: 1},
+		{name: "split across reads", chunks: []string{"a\x1b", "cb"}, want: 1},
+		{name: "multiple", chunks: []string{"\x1bc\x1b\x1bc"}, want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r risTracker
+			got := 0
+			for _, c := range tt.chunks {
+				got += r.feed([]byte(c))
+			}
+			if got != tt.want {
+				t.Errorf("got %d resets, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResetCount(t *testing.T) {
+	var events int32
+	emu := New(4, 40).
+		Command("sh", "-c", "printf 'before'; sleep 0.1; printf '\\033cafter'; sleep 5").
+		OnTerminalReset(func() { atomic.AddInt32(&events, 1) })
+	t.Cleanup(func() { _ = emu.Close() })
+
+	if err := emu.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	emu.AssertLineEqual(t, 0, "after")
+	if err := emu.Reset(); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	if !emu.WaitStable(50*time.Millisecond, 2*time.Second) {
+		t.Fatal("screen did not stabilize")
+	}
+
+	if got := emu.ResetCount(); got != 1 {
+		t.Errorf("expected 1 reset, got %d", got)
+	}
+	if got := atomic.LoadInt32(&events); got != 1 {
+		t.Errorf("expected 1 reset event, got %d", got)
+	}
+}
@@ -1,15 +1,163 @@
 package vtermtest
 
 import (
+	"errors"
+	"fmt"
 	"strings"
+	"time"
 
 	libvterm "github.com/mattn/go-libvterm"
 	"github.com/mattn/go-runewidth"
 )
 
 // GetScreenText returns the entire terminal screen as a string.
-// Lines are trimmed of trailing spaces and joined with newlines.
+// Lines are trimmed of trailing spaces and joined with newlines, or with the
+// separator set by WithScreenLineEnding. Use GetScreenTextRaw to keep the
+// trailing spaces.
+// Only cell contents are read; cursor position, visibility and blinking are
+// not part of the text, so a blinking cursor does not prevent WaitStable from settling.
+// After Close it returns the last screen the program rendered.
 func (e *Emulator) GetScreenText() (string, error) {
+	text, err := e.getScreenText()
+	if err != nil || e.lineEnding == "" || e.lineEnding == "\n" {
+		return text, err
+	}
+	return strings.ReplaceAll(text, "\n", e.lineEnding), nil
+}
+
+// GetScreenTextRaw returns the entire terminal screen like GetScreenText, but
+// without trimming: every line is padded with spaces to exactly cols columns,
+// so padding written by the program (progress bars, right-aligned borders) can
+// be asserted on. Returns an error if the emulator is not running.
+func (e *Emulator) GetScreenTextRaw() (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.screen == nil {
+		return "", errors.New("emulator not started")
+	}
+
+	lines := make([]string, e.rows)
+	for row := 0; row < int(e.rows); row++ {
+		lines[row] = e.getLine(row)
+	}
+
+	sep := e.lineEnding
+	if sep == "" {
+		sep = "\n"
+	}
+	return strings.Join(lines, sep), nil
+}
+
+// GetScreenGrid returns the screen as a rows-by-cols grid of runes, for
+// column-precise checks without re-parsing the text. Empty cells are spaces; a
+// wide character occupies its leading cell and the cell after it holds 0.
+func (e *Emulator) GetScreenGrid() ([][]rune, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.screen == nil {
+		return nil, errors.New("emulator not started")
+	}
+
+	grid := make([][]rune, e.rows)
+	for row := range grid {
+		line := make([]rune, e.cols)
+		for col := 0; col < len(line); col++ {
+			line[col] = ' '
+			cell, err := e.screen.GetCell(libvterm.NewPos(row, col))
+			if err != nil || cell == nil {
+				continue
+			}
+			chars := cell.Chars()
+			if len(chars) == 0 || chars[0] <= 0 {
+				continue
+			}
+			if e.conceal != ConcealBlank || cell.Attrs().Font != concealFont {
+				line[col] = chars[0]
+			}
+			if cell.Width() == 2 && col+1 < len(line) {
+				col++
+				line[col] = 0
+			}
+		}
+		grid[row] = line
+	}
+	return grid, nil
+}
+
+// OnScreenChange registers fn to be called with the screen text, as returned by
+// GetScreenText, each time output from the program changes it. fn runs on the
+// reader goroutine after the output has been rendered and without the emulator's
+// lock held, so it may call back into the Emulator, but it must not block: output
+// is not read while it runs. Output that damages the screen without changing its
+// text (colors, identical redraws) does not call fn; Resize and Reset do not
+// call it themselves. Returns self for method chaining.
+func (e *Emulator) OnScreenChange(fn func(screen string)) *Emulator {
+	e.onScreenChange = fn
+	return e
+}
+
+// screenChange reports the screen text for OnScreenChange if libvterm reported
+// damage since the last call and the text differs from the last one reported.
+// The rendering is kept in the screen text cache. The caller must hold e.mu.
+func (e *Emulator) screenChange() (string, bool) {
+	damaged := e.damaged
+	e.damaged = false
+	if e.onScreenChange == nil || !damaged {
+		return "", false
+	}
+
+	text := e.screenText()
+	e.cachedText = text
+	e.cachedGen = e.generation
+	e.cacheValid = true
+	if text == e.notifiedScreen {
+		return "", false
+	}
+	e.notifiedScreen = text
+
+	if e.lineEnding != "" && e.lineEnding != "\n" {
+		text = strings.ReplaceAll(text, "\n", e.lineEnding)
+	}
+	return text, true
+}
+
+// WithScreenLineEnding sets the separator GetScreenText uses to join rows,
+// e.g. "\r\n" to compare against CRLF golden files. The default is "\n".
+// Assertions on the whole screen (AssertScreenEqual, AssertScreenContains,
+// AssertScreenFunc) see the same text; line-based helpers are unaffected.
+// Returns self for method chaining.
+func (e *Emulator) WithScreenLineEnding(sep string) *Emulator {
+	e.lineEnding = sep
+	return e
+}
+
+// getScreenText returns the screen text with rows joined by "\n".
+func (e *Emulator) getScreenText() (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.screen == nil {
+		// Empty before Start; the last frame after Close
+		return e.finalScreen, nil
+	}
+
+	// Reuse the last rendering if nothing was written since
+	if e.cacheValid && e.cachedGen == e.generation {
+		return e.cachedText, nil
+	}
+
+	e.cachedText = e.screenText()
+	e.cachedGen = e.generation
+	e.cacheValid = true
+	return e.cachedText, nil
+}
+
+// GetScreenTextWithCursor returns the screen like GetScreenText, with marker
+// (e.g. '▮') drawn at the cursor's cell. Intended for debugging cursor-relative
+// assertions; GetScreenText itself is unaffected.
+func (e *Emulator) GetScreenTextWithCursor(marker rune) (string, error) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
@@ -17,63 +165,202 @@ func (e *Emulator) GetScreenText() (string, error) {
 		return "", nil
 	}
 
+	cursorRow, cursorCol := e.state.GetCursorPos()
+
 	lines := make([]string, e.rows)
 	for row := 0; row < int(e.rows); row++ {
 		line := e.getLine(row)
+		if row == cursorRow {
+			line = placeMarker(line, cursorCol, marker)
+		}
 		lines[row] = strings.TrimRight(line, " ")
 	}
 
 	return strings.Join(lines, "\n"), nil
 }
 
+// placeMarker replaces the rune covering display column col with marker,
+// padding the line with spaces if it is shorter. Zero-width runes (combining
+// characters) share the cell of the rune before them and are replaced with it.
+func placeMarker(line string, col int, marker rune) string {
+	runes := []rune(line)
+	width := 0
+	for i, r := range runes {
+		w := runewidth.RuneWidth(r)
+		if w == 0 {
+			continue
+		}
+		if col < width+w {
+			end := i + 1
+			for end < len(runes) && runewidth.RuneWidth(runes[end]) == 0 {
+				end++
+			}
+			return string(runes[:i]) + string(marker) + string(runes[end:])
+		}
+		width += w
+	}
+	return string(runes) + strings.Repeat(" ", col-width) + string(marker)
+}
+
+// screenText renders the screen as text. The caller must hold e.mu.
+func (e *Emulator) screenText() string {
+	lines := make([]string, e.rows)
+	for row := 0; row < int(e.rows); row++ {
+		line := e.getLine(row)
+		lines[row] = strings.TrimRight(line, " ")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// getLine renders a row as text, including combining characters. A wide
+// character is followed by a continuation cell, which libvterm stores as -1; the
+// width libvterm assigned to the character is used to skip it, since runewidth
+// may disagree for ambiguous or newer characters. The caller must hold e.mu.
 func (e *Emulator) getLine(row int) string {
 	var line strings.Builder
-	currentCol := 0
 
 	for col := 0; col < int(e.cols); {
 		pos := libvterm.NewPos(row, col)
 		cell, err := e.screen.GetCell(pos)
-		
+
 		if err != nil || cell == nil {
 			line.WriteRune(' ')
-			currentCol++
 			col++
 			continue
 		}
 
 		chars := cell.Chars()
-		if len(chars) == 0 || chars[0] == 0 {
+		if len(chars) == 0 || chars[0] <= 0 {
 			line.WriteRune(' ')
-			currentCol++
 			col++
 			continue
 		}
 
-		r := chars[0]
-		line.WriteRune(r)
-		
-		width := runewidth.RuneWidth(r)
-		if width == 0 {
+		width := cell.Width()
+		if width < 1 {
 			width = 1
 		}
-		
-		currentCol += width
+
+		if e.conceal == ConcealBlank && cell.Attrs().Font == concealFont {
+			line.WriteString(strings.Repeat(" ", width))
+		} else {
+			line.WriteString(string(e.cellRunes(row, col, width, chars[0])))
+		}
+
 		col += width
 	}
 
 	return line.String()
 }
 
+// maxCharsPerCell is libvterm's VTERM_MAX_CHARS_PER_CELL: a base character
+// plus up to five combining characters.
+const maxCharsPerCell = 6
+
+// cellRunes returns the base character of a cell followed by any combining
+// characters (accents, zero width joiners) libvterm stored with it. Chars()
+// only reports as many runes as the cell is wide, so the whole cell is read
+// with GetChars instead. The caller must hold e.mu.
+func (e *Emulator) cellRunes(row, col, width int, base rune) []rune {
+	runes := make([]rune, maxCharsPerCell)
+	if n := e.screen.GetChars(&runes, libvterm.NewRect(row, row+1, col, col+width)); n == 0 {
+		return []rune{base}
+	}
+	return runes
+}
+
+// cellAt returns the rune and width of a cell. Blank cells return ' ', and the
+// continuation cell of a wide character (stored by libvterm as -1) returns 0.
+// The caller must hold e.mu.
+func (e *Emulator) cellAt(row, col int) (rune, int, error) {
+	cell, err := e.screen.GetCell(libvterm.NewPos(row, col))
+	if err != nil {
+		return 0, 0, err
+	}
+	chars := cell.Chars()
+	switch {
+	case len(chars) == 0 || chars[0] == 0:
+		return ' ', cell.Width(), nil
+	case chars[0] < 0:
+		return 0, cell.Width(), nil
+	}
+	return chars[0], cell.Width(), nil
+}
+
 // GetLine returns a specific line from the terminal screen.
-// Row index starts at 0. Trailing spaces are trimmed.
+// Row index starts at 0; negative rows count from the bottom (-1 is the last row).
+// Trailing spaces are trimmed.
+// Lines rewritten with a carriage return reflect the final rendered state, as on a
+// real terminal: shorter text only overwrites the cells it covers, so programs
+// must erase the rest of the line (ESC[K) for leftover characters to disappear.
 func (e *Emulator) GetLine(row int) (string, error) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
+	if row < 0 {
+		if row < -int(e.rows) {
+			return "", fmt.Errorf("row %d out of range for %d rows", row, e.rows)
+		}
+		row += int(e.rows)
+	}
 	if e.screen == nil || row >= int(e.rows) {
 		return "", nil
 	}
 
 	line := e.getLine(row)
 	return strings.TrimRight(line, " "), nil
-}
\ No newline at end of file
+}
+
+// IndexedLine is a screen line together with the row it occupies.
+type IndexedLine struct {
+	Row  int    // 0-based screen row
+	Text string // line text with trailing spaces trimmed
+}
+
+// GetScreenLinesIndexed returns the screen lines with their row indices, so a
+// line can be related to its neighbours (e.g. the error just above the prompt).
+// When skipBlank is true, rows without content are left out.
+func (e *Emulator) GetScreenLinesIndexed(skipBlank bool) ([]IndexedLine, error) {
+	screen, err := e.getScreenText()
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []IndexedLine
+	for row, text := range strings.Split(screen, "\n") {
+		if skipBlank && strings.TrimSpace(text) == "" {
+			continue
+		}
+		lines = append(lines, IndexedLine{Row: row, Text: text})
+	}
+	return lines, nil
+}
+
+// LineLastChanged returns the time the given row was last modified.
+// Row index starts at 0. Returns the zero time if the row has not changed since Start.
+func (e *Emulator) LineLastChanged(row int) time.Time {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.lineChanged[row]
+}
+
+// ContentRowCount returns the number of screen rows that contain non-space characters.
+func (e *Emulator) ContentRowCount() (int, error) {
+	screen, err := e.getScreenText()
+	if err != nil {
+		return 0, err
+	}
+	return contentRowCount(screen), nil
+}
+
+func contentRowCount(screen string) int {
+	count := 0
+	for _, line := range strings.Split(screen, "\n") {
+		if strings.TrimSpace(line) != "" {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,26 @@
+package vtermtest
+
+import "testing"
+
+func TestPlaceMarker(t *testing.T) {
+	tests := []struct {
+		line string
+		col  int
+		want string
+	}{
+		{"hello", 0, "▮ello"},
+		{"hello", 4, "hell▮"},
+		{"hello", 7, "hello  ▮"},
+		{"日本", 2, "日▮"},
+		{"cafe\u0301!", 3, "caf▮!"},
+		{"cafe\u0301!", 4, "cafe\u0301▮"},
+		{"cafe\u0301", 5, "cafe\u0301 ▮"},
+		{"", 3, "   ▮"},
+	}
+
+	for _, tt := range tests {
+		if got := placeMarker(tt.line, tt.col, '▮'); got != tt.want {
+			t.Errorf("placeMarker(%q, %d) = %q, want %q", tt.line, tt.col, got, tt.want)
+		}
+	}
+}
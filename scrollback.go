@@ -0,0 +1,217 @@
+package vtermtest
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	libvterm "github.com/mattn/go-libvterm"
+)
+
+// Marker is a position in the program's line output, returned by GetNewLines.
+// The zero Marker is the first line of output.
+type Marker struct {
+	line int // absolute line: lines scrolled off the screen plus the screen row
+}
+
+// EnableScrollback keeps the lines that scroll off the top of the screen, up to
+// maxLines (unlimited if maxLines <= 0), so GetScrollback and GetNewLines can
+// return them. Lines leaving the alternate screen or a scroll region that does
+// not start at the top row are not kept, as on common terminals.
+// Returns self for method chaining.
+func (e *Emulator) EnableScrollback(maxLines int) *Emulator {
+	e.scrollbackEnabled = true
+	e.scrollbackMax = maxLines
+	return e
+}
+
+// GetScrollback returns the lines that scrolled off the screen, oldest first,
+// with trailing spaces trimmed. Scrollback must be enabled with EnableScrollback().
+// Returns a copy of the collected lines.
+func (e *Emulator) GetScrollback() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	result := make([]string, len(e.scrollback))
+	copy(result, e.scrollback)
+	return result
+}
+
+// ScrollbackLen returns the number of lines kept in the scrollback.
+// Scrollback must be enabled with EnableScrollback().
+func (e *Emulator) ScrollbackLen() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.scrollback)
+}
+
+// GetScrollbackLine returns the nth line kept in the scrollback, with trailing
+// spaces trimmed. Line 0 is the oldest; line ScrollbackLen()-1 is the one just
+// above the top row of the screen.
+func (e *Emulator) GetScrollbackLine(n int) (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if n < 0 || n >= len(e.scrollback) {
+		return "", fmt.Errorf("scrollback line %d out of range for %d lines", n, len(e.scrollback))
+	}
+	return e.scrollback[n], nil
+}
+
+// GetNewLines returns the lines output since the marker, oldest first, and a
+// marker for the next call. Pass the zero Marker to start from the beginning.
+//
+// Lines are returned once the cursor has moved below them, so a line still being
+// written is returned by a later call. Lines that scrolled off the screen are
+// included when scrollback is enabled with EnableScrollback; otherwise they are
+// skipped. Long lines wrapped by the terminal are returned as one line per row.
+// Lines rewritten in place, or output after clearing the screen that lands above
+// the marker, are not reported again.
+func (e *Emulator) GetNewLines(since Marker) ([]string, Marker) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.screen == nil {
+		return nil, since
+	}
+
+	cursorRow, _ := e.state.GetCursorPos()
+	end := e.scrolledLines + cursorRow
+
+	// Lines scrolled off before the oldest kept line are gone
+	start := since.line
+	if oldest := e.scrolledLines - len(e.scrollback); start < oldest {
+		start = oldest
+	}
+	if start >= end {
+		return nil, since
+	}
+
+	lines := make([]string, 0, end-start)
+	for line := start; line < end; line++ {
+		if line < e.scrolledLines {
+			lines = append(lines, e.scrollback[line-(e.scrolledLines-len(e.scrollback))])
+		} else {
+			lines = append(lines, strings.TrimRight(e.getLine(line-e.scrolledLines), " "))
+		}
+	}
+	return lines, Marker{line: end}
+}
+
+// onMoveRect counts lines scrolled off the top of the primary screen and, with
+// scrollback enabled, keeps them. libvterm has already moved its own cells when
+// this is called, so the lines are taken from the copy of the screen rows.
+// It is called by libvterm while e.mu is held by the writer.
+func (e *Emulator) onMoveRect(dest, src *libvterm.Rect) int {
+	n := e.scrolledOff(dest, src)
+	e.scrolledLines += n
+	if !e.scrollbackEnabled {
+		return 0
+	}
+
+	for row := 0; row < n && row < len(e.shadow); row++ {
+		e.scrollback = append(e.scrollback, e.shadow[row])
+	}
+	if e.scrollbackMax > 0 && len(e.scrollback) > e.scrollbackMax {
+		e.scrollback = e.scrollback[len(e.scrollback)-e.scrollbackMax:]
+	}
+	if e.moveShadow(dest, src) {
+		// The copy already follows the move; damaging the rows instead would
+		// make the next write re-read all of them
+		e.noteDamage(dest)
+		return 1
+	}
+	return 0
+}
+
+// scrolledOff returns how many lines a move of screen cells scrolls off the top
+// of the primary screen. The caller must hold e.mu.
+func (e *Emulator) scrolledOff(dest, src *libvterm.Rect) int {
+	if dest.StartRow() != 0 || dest.StartCol() != 0 || dest.EndCol() != int(e.cols) || src.StartRow() <= dest.StartRow() {
+		return 0
+	}
+	if e.modes.enabled(ModeAltScreen) || e.modes.enabled(47) || e.modes.enabled(1047) {
+		return 0
+	}
+	return src.StartRow() - dest.StartRow()
+}
+
+// moveShadow applies a move of screen cells to the copy of the screen rows and
+// reports whether the moved rows kept their copy. Otherwise the rows touched by
+// the move are re-read before the next write. The caller must hold e.mu.
+func (e *Emulator) moveShadow(dest, src *libvterm.Rect) bool {
+	if len(e.shadow) != int(e.rows) {
+		return false // resized; syncShadow re-reads every row
+	}
+	if dest.StartCol() != 0 || dest.EndCol() != int(e.cols) || src.StartRow() <= dest.StartRow() || src.EndRow() > len(e.shadow) {
+		e.markShadowDirty(dest.StartRow(), dest.EndRow())
+		e.markShadowDirty(src.StartRow(), src.EndRow())
+		return false
+	}
+
+	copy(e.shadow[dest.StartRow():], e.shadow[src.StartRow():src.EndRow()])
+	copy(e.shadowDirty[dest.StartRow():], e.shadowDirty[src.StartRow():src.EndRow()])
+	return true
+}
+
+// markShadowDirty marks rows [start, end) of the copy of the screen rows as
+// changed. The caller must hold e.mu.
+func (e *Emulator) markShadowDirty(start, end int) {
+	if start < 0 {
+		start = 0
+	}
+	for row := start; row < end && row < len(e.shadowDirty); row++ {
+		e.shadowDirty[row] = true
+	}
+}
+
+// syncShadow re-reads the rows of the screen that changed since the last call
+// into the copy of the screen rows. The caller must hold e.mu.
+func (e *Emulator) syncShadow() {
+	if len(e.shadow) != int(e.rows) {
+		e.shadow = make([]string, e.rows)
+		e.shadowDirty = make([]bool, e.rows)
+		e.markShadowDirty(0, int(e.rows))
+	}
+	for row, dirty := range e.shadowDirty {
+		if dirty {
+			e.shadow[row] = strings.TrimRight(e.getLine(row), " ")
+			e.shadowDirty[row] = false
+		}
+	}
+}
+
+// writeVT writes program output to libvterm. With scrollback enabled, the output
+// is written in short pieces, and before each one the copy of the screen rows is
+// brought up to date, so that lines scrolled off the top can be kept. Each piece
+// starts at most one escape sequence and holds at most one row of text, so rows
+// written and scrolled off within one piece are rare. The caller must hold e.mu.
+func (e *Emulator) writeVT(data []byte) error {
+	if !e.scrollbackEnabled {
+		_, err := e.vt.Write(data)
+		return err
+	}
+
+	for len(data) > 0 {
+		n := len(data)
+		if i := bytes.IndexAny(data, "\n\v\f"); i >= 0 && i+1 < n {
+			n = i + 1
+		}
+		if i := bytes.IndexByte(data[1:], 0x1B); i >= 0 && i+1 < n {
+			n = i + 1
+		}
+		if n > int(e.cols) {
+			n = int(e.cols)
+		}
+		chunk := data[:n]
+		data = data[n:]
+
+		// Deliver merged damage first so the changed rows are known
+		e.screen.Flush()
+		e.syncShadow()
+		if _, err := e.vt.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package vtermtest_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/c-bata/vtermtest"
+	"github.com/c-bata/vtermtest/keys"
+)
+
+func TestGetNewLines(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(4, 40).
+		Command("sh", "-c", "stty -echo; for i in 1 2 3 4 5 6 7 8 9 10; do echo \"line $i\"; done; read a; printf 'line 11\\nline 12\\npartial'; sleep 5").
+		Env("LANG=C.UTF-8").
+		EnableScrollback(0)
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	if err := emu.WaitFor("line 10", 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	var want []string
+	for i := 1; i <= 10; i++ {
+		want = append(want, fmt.Sprintf("line %d", i))
+	}
+	lines, marker := emu.GetNewLines(vtermtest.Marker{})
+	if !reflect.DeepEqual(lines, want) {
+		t.Fatalf("expected lines 1-10, got %q", lines)
+	}
+	if got := emu.GetScrollback(); !reflect.DeepEqual(got, want[:7]) {
+		t.Errorf("expected lines 1-7 in scrollback, got %q", got)
+	}
+	if n := emu.ScrollbackLen(); n != 7 {
+		t.Errorf("expected 7 scrollback lines, got %d", n)
+	}
+	if line, err := emu.GetScrollbackLine(6); err != nil || line != "line 7" {
+		t.Errorf("expected scrollback line 6 to be %q, got %q (err: %v)", "line 7", line, err)
+	}
+	if _, err := emu.GetScrollbackLine(7); err == nil {
+		t.Error("expected an error for a scrollback line out of range")
+	}
+
+	if err := emu.KeyPress(keys.Enter); err != nil {
+		t.Fatal(err)
+	}
+	if err := emu.WaitFor("partial", 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	// The line still being written is not returned yet
+	lines, _ = emu.GetNewLines(marker)
+	if !reflect.DeepEqual(lines, []string{"line 11", "line 12"}) {
+		t.Errorf("expected lines 11-12, got %q", lines)
+	}
+}
+
+func TestScrollbackKeepsBurstOutput(t *testing.T) {
+	ctx := context.Background()
+
+	// All lines arrive in one write, including a line wrapped over three rows
+	// and cursor movement that scrolls without a line feed
+	emu := vtermtest.New(4, 10).
+		Command("sh", "-c", "printf 'l1\\nl2\\nl3\\nl4\\nl5\\nabcdefghij0123456789XYZ\\nl7\\033Dl8\\nl9\\n'; sleep 5").
+		Env("LANG=C.UTF-8").
+		EnableScrollback(0)
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	if err := emu.WaitFor("l9", 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"l1", "l2", "l3", "l4", "l5", "abcdefghij", "0123456789", "XYZ"}
+	if got := emu.GetScrollback(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected scrollback %q, got %q", want, got)
+	}
+}
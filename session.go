@@ -0,0 +1,72 @@
+package vtermtest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/c-bata/vtermtest/keys"
+)
+
+// RunThen runs prep against the already-started emulator, e.g. fixture setup typed
+// into a shell with RunShellCommand, and returns once prep has finished. The
+// emulator is left running, so the interaction under test continues in the same
+// session without starting a new process. Returns an error if the emulator is not
+// running or prep fails.
+func (e *Emulator) RunThen(prep func(*Emulator) error) error {
+	e.mu.Lock()
+	if e.state == nil {
+		err := e.stateErr()
+		e.mu.Unlock()
+		return err
+	}
+	e.mu.Unlock()
+
+	if err := prep(e); err != nil {
+		return fmt.Errorf("prep: %w", err)
+	}
+	return nil
+}
+
+// RunShellCommand types line followed by Enter into an interactive shell and
+// waits until the shell prompt is shown again on the cursor row, i.e. until the
+// command has finished. prompt is the shell prompt text (e.g. "$ ").
+// Returns an error including the last screen if the prompt does not return within timeout.
+func (e *Emulator) RunShellCommand(line, prompt string, timeout time.Duration) error {
+	e.mu.Lock()
+	startGen := e.generation
+	e.mu.Unlock()
+
+	if err := e.KeyPress(keys.Text(line), keys.Enter); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(timeout)
+	want := strings.TrimRight(prompt, " ")
+	for {
+		e.mu.Lock()
+		changed := e.generation != startGen
+		e.mu.Unlock()
+
+		if changed {
+			row, _, err := e.GetCursorPosition()
+			if err != nil {
+				return err
+			}
+			got, err := e.GetLine(row - 1)
+			if err != nil {
+				return err
+			}
+			if got == want {
+				return nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			screen, _ := e.GetScreenText()
+			return fmt.Errorf("prompt %q did not return after %q within timeout\nCurrent screen content:\n%s", prompt, line, screen)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,44 @@
+package vtermtest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/c-bata/vtermtest"
+)
+
+func TestRunShellCommand(t *testing.T) {
+	ctx := context.Background()
+
+	emu := vtermtest.New(10, 60).
+		Command("sh").
+		Env("LANG=C.UTF-8", "PS1=$ ")
+
+	if err := emu.RunThen(func(*vtermtest.Emulator) error { return nil }); err == nil {
+		t.Error("expected an error before Start")
+	}
+
+	if err := emu.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer emu.Close()
+
+	err := emu.RunThen(func(emu *vtermtest.Emulator) error {
+		if err := emu.WaitFor("$", 2*time.Second); err != nil {
+			return err
+		}
+		if err := emu.RunShellCommand("FIXTURE=ready", "$ ", 2*time.Second); err != nil {
+			return err
+		}
+		return emu.RunShellCommand("sleep 0.2", "$ ", 2*time.Second)
+	})
+	if err != nil {
+		t.Fatalf("prep failed: %v", err)
+	}
+
+	if err := emu.RunShellCommand("echo fixture is $FIXTURE", "$ ", 2*time.Second); err != nil {
+		t.Fatalf("command failed: %v", err)
+	}
+	emu.AssertScreenContains(t, "fixture is ready")
+}
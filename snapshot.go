@@ -0,0 +1,32 @@
+package vtermtest
+
+// Snapshot is a saved copy of the screen text, to compare against later screens.
+type Snapshot struct {
+	text string
+}
+
+// Snapshot saves the current screen text.
+func (e *Emulator) Snapshot() (*Snapshot, error) {
+	text, err := e.getScreenText()
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{text: text}, nil
+}
+
+// String returns the saved screen text.
+func (s *Snapshot) String() string {
+	return s.text
+}
+
+// DiffWithTolerance reports whether the current screen of e differs from the
+// snapshot in at most maxDifferingCells display cells. Use it when a few cells
+// (a clock, a spinner) are nondeterministic but the rest of the layout must match.
+// A wide character counts as one differing cell for each column it covers.
+func (s *Snapshot) DiffWithTolerance(e *Emulator, maxDifferingCells int) bool {
+	text, err := e.getScreenText()
+	if err != nil {
+		return false
+	}
+	return differingCells(s.text, text) <= maxDifferingCells
+}
@@ -0,0 +1,42 @@
+package vtermtest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/c-bata/vtermtest"
+	"github.com/c-bata/vtermtest/keys"
+)
+
+func TestSnapshotDiffWithTolerance(t *testing.T) {
+	emu := vtermtest.New(4, 40).
+		Command("sh", "-c", "echo 'status: ok 12:00:01'; read a; printf '\\033[1;18H12'; sleep 5").
+		Env("LANG=C.UTF-8", "TERM=xterm")
+	t.Cleanup(func() { _ = emu.Close() })
+
+	if err := emu.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	emu.AssertLineEqual(t, 0, "status: ok 12:00:01")
+
+	snap, err := emu.Snapshot()
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if !snap.DiffWithTolerance(emu, 0) {
+		t.Fatalf("snapshot should match the screen it was taken from:\n%s", snap)
+	}
+
+	// Rewrite the seconds, changing two cells
+	if err := emu.KeyPress(keys.Enter); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	emu.AssertLineEqual(t, 0, "status: ok 12:00:12")
+
+	if snap.DiffWithTolerance(emu, 1) {
+		t.Error("expected two differing cells to exceed a tolerance of 1")
+	}
+	if !snap.DiffWithTolerance(emu, 2) {
+		t.Error("expected two differing cells to be within a tolerance of 2")
+	}
+}
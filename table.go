@@ -0,0 +1,61 @@
+package vtermtest
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GetTableRows splits rows [startRow, endRow) into columns separated by runs of at
+// least minGap spaces, for programs that print aligned tables. Single spaces inside
+// a cell are kept, so use minGap 2 for tables with multi-word cells.
+// Rows may have different numbers of columns; blank rows yield no columns.
+// Wide characters occupy one rune in a cell regardless of their display width.
+func (e *Emulator) GetTableRows(startRow, endRow int, minGap int) ([][]string, error) {
+	if minGap < 1 {
+		return nil, fmt.Errorf("minGap must be at least 1, got %d", minGap)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.screen == nil {
+		return nil, nil
+	}
+	if startRow < 0 || endRow > int(e.rows) || startRow > endRow {
+		return nil, fmt.Errorf("invalid row range [%d, %d) for %d rows", startRow, endRow, e.rows)
+	}
+
+	rows := make([][]string, 0, endRow-startRow)
+	for row := startRow; row < endRow; row++ {
+		rows = append(rows, splitColumns(e.getLine(row), minGap))
+	}
+	return rows, nil
+}
+
+// splitColumns splits line on runs of at least minGap spaces, ignoring
+// leading and trailing spaces.
+func splitColumns(line string, minGap int) []string {
+	line = strings.Trim(line, " ")
+	if line == "" {
+		return nil
+	}
+
+	var columns []string
+	var cell strings.Builder
+	spaces := 0
+	for _, r := range line {
+		if r == ' ' {
+			spaces++
+			continue
+		}
+		if spaces >= minGap {
+			columns = append(columns, cell.String())
+			cell.Reset()
+		} else {
+			cell.WriteString(strings.Repeat(" ", spaces))
+		}
+		spaces = 0
+		cell.WriteRune(r)
+	}
+	return append(columns, cell.String())
+}
@@ -0,0 +1,88 @@
+package vtermtest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSplitColumns(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		minGap int
+		want   []string
+	}{
+		{
+			name:   "aligned columns",
+			line:   "NAME     READY   STATUS",
+			minGap: 2,
+			want:   []string{"NAME", "READY", "STATUS"},
+		},
+		{
+			name:   "single spaces kept inside cells",
+			line:   "  my pod   1/1   Running  ",
+			minGap: 2,
+			want:   []string{"my pod", "1/1", "Running"},
+		},
+		{
+			name:   "gap of one",
+			line:   "a b  c",
+			minGap: 1,
+			want:   []string{"a", "b", "c"},
+		},
+		{
+			name:   "wide characters",
+			line:   "名前    状態",
+			minGap: 2,
+			want:   []string{"名前", "状態"},
+		},
+		{
+			name:   "blank",
+			line:   "     ",
+			minGap: 2,
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitColumns(tt.line, tt.minGap); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitColumns(%q, %d) = %q, want %q", tt.line, tt.minGap, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetTableRows(t *testing.T) {
+	emu := New(6, 40).
+		Command("printf", "NAME    AGE  CITY\\nAlice   30   New York\\n\\n名前    25\\n").
+		Env("LANG=C.UTF-8")
+	t.Cleanup(func() { _ = emu.Close() })
+
+	if err := emu.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	emu.AssertLineEqual(t, 3, "名前    25")
+
+	got, err := emu.GetTableRows(0, 4, 2)
+	if err != nil {
+		t.Fatalf("GetTableRows: %v", err)
+	}
+	want := [][]string{
+		{"NAME", "AGE", "CITY"},
+		{"Alice", "30", "New York"},
+		nil,
+		{"名前", "25"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetTableRows = %q, want %q", got, want)
+	}
+
+	if _, err := emu.GetTableRows(0, 7, 2); err == nil {
+		t.Error("expected an error for a range past the last row")
+	}
+	if _, err := emu.GetTableRows(0, 1, 0); err == nil {
+		t.Error("expected an error for minGap 0")
+	}
+}
@@ -0,0 +1,47 @@
+package vtermtest
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// tracer logs PTY traffic to a writer for debugging.
+type tracer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// WithTraceWriter logs every chunk read from and written to the PTY to w,
+// with a timestamp, the direction and a hex dump including printable characters.
+// Reads are marked "<<" (program output), writes ">>" (input sent to the program,
+// including automatic terminal responses). Tracing is off by default.
+func (e *Emulator) WithTraceWriter(w io.Writer) *Emulator {
+	e.trace.w = w
+	return e
+}
+
+func (t *tracer) log(direction string, data []byte) {
+	if t.w == nil || len(data) == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	fmt.Fprintf(t.w, "%s %s %d bytes\n%s", time.Now().Format("15:04:05.000000"), direction, len(data), hex.Dump(data))
+}
+
+// writePTY writes data to the PTY, tracing and collecting it if enabled.
+// Returns the number of bytes written.
+func (e *Emulator) writePTY(data []byte) (int, error) {
+	e.trace.log(">>", data)
+	if e.collectSentBytes {
+		e.sentMu.Lock()
+		e.sentBytes = append(e.sentBytes, data...)
+		e.sentMu.Unlock()
+	}
+	return e.ptmx.Write(data)
+}
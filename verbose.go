@@ -0,0 +1,55 @@
+package vtermtest
+
+import (
+	"runtime"
+	"strings"
+	"unicode"
+)
+
+// WithAssertVerbose logs each retrying assertion as it runs: the assertion's name
+// when it starts, and whether it passed or failed with the number of attempts
+// used. Pass t.Logf to interleave the trace with test output. Off by default.
+func (e *Emulator) WithAssertVerbose(logf func(format string, args ...interface{})) *Emulator {
+	e.assertCfg.logf = logf
+	return e
+}
+
+// logAssert writes to the verbose assertion log, if enabled.
+func (e *Emulator) logAssert(format string, args ...interface{}) {
+	if e.assertCfg.logf != nil {
+		e.assertCfg.logf(format, args...)
+	}
+}
+
+// assertionName returns the name of the outermost exported Emulator method on
+// the call stack of assertWithRetry, e.g. "AssertModeEnabled" rather than the
+// unexported assertMode helper it calls.
+func assertionName() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs) // skip runtime.Callers and assertionName
+	frames := runtime.CallersFrames(pcs[:n])
+
+	// The first frame is assertWithRetry; its prefix identifies Emulator methods
+	first, _ := frames.Next()
+	i := strings.LastIndex(first.Function, ".")
+	if i < 0 {
+		return "assertion"
+	}
+	prefix := first.Function[:i+1]
+
+	name := "assertion"
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, prefix) {
+			break
+		}
+		method := strings.TrimPrefix(frame.Function, prefix)
+		if !strings.Contains(method, ".") && method != "" && unicode.IsUpper(rune(method[0])) {
+			name = method
+		}
+		if !more {
+			break
+		}
+	}
+	return name
+}
@@ -0,0 +1,101 @@
+package vtermtest
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// Window size queries (XTWINOPS) answered by the emulator.
+const (
+	winopsReportPixelSize = 14 // CSI 14 t -> CSI 4 ; height ; width t
+	winopsReportTextSize  = 18 // CSI 18 t -> CSI 8 ; rows ; cols t
+)
+
+// Cell size used to derive the pixel size when WithPixelSize is not set.
+const (
+	defaultCellWidth  = 8
+	defaultCellHeight = 16
+)
+
+// maxPendingWinops bounds how much of an incomplete query is kept between reads.
+const maxPendingWinops = 16
+
+// winopsTracker finds window size queries (CSI Ps t) in the program's output.
+// Queries split across reads are completed on the next feed.
+type winopsTracker struct {
+	pending []byte
+}
+
+// feed returns the window size queries found in data, in order.
+func (w *winopsTracker) feed(data []byte) []int {
+	buf := data
+	if len(w.pending) > 0 {
+		buf = append(w.pending, data...)
+		w.pending = nil
+	}
+
+	var queries []int
+	for i := 0; i < len(buf); i++ {
+		if buf[i] != 0x1B {
+			continue
+		}
+
+		rest := buf[i:]
+		if len(rest) < 2 {
+			w.pending = append([]byte(nil), rest...)
+			return queries
+		}
+		if rest[1] != '[' {
+			continue
+		}
+
+		j := 2
+		for j < len(rest) && (rest[j] == ';' || (rest[j] >= '0' && rest[j] <= '9')) {
+			j++
+		}
+		if j == len(rest) {
+			if len(rest) <= maxPendingWinops {
+				w.pending = append([]byte(nil), rest...)
+			}
+			return queries
+		}
+
+		if rest[j] == 't' {
+			param := rest[2:j]
+			if k := bytes.IndexByte(param, ';'); k >= 0 {
+				param = param[:k]
+			}
+			if n, err := strconv.Atoi(string(param)); err == nil && (n == winopsReportPixelSize || n == winopsReportTextSize) {
+				queries = append(queries, n)
+			}
+		}
+		i += j
+	}
+	return queries
+}
+
+// WithPixelSize sets the text area size in pixels reported in reply to CSI 14 t.
+// By default it is derived from the terminal size with 8x16 pixel cells.
+// Returns self for method chaining.
+func (e *Emulator) WithPixelSize(width, height int) *Emulator {
+	e.pixelWidth = width
+	e.pixelHeight = height
+	return e
+}
+
+// winopsReply returns the reply to a window size query. The caller must hold e.mu.
+func (e *Emulator) winopsReply(query int) []byte {
+	switch query {
+	case winopsReportPixelSize:
+		width, height := e.pixelWidth, e.pixelHeight
+		if width <= 0 || height <= 0 {
+			width = int(e.cols) * defaultCellWidth
+			height = int(e.rows) * defaultCellHeight
+		}
+		return []byte(fmt.Sprintf("\x1b[4;%d;%dt", height, width))
+	case winopsReportTextSize:
+		return []byte(fmt.Sprintf("\x1b[8;%d;%dt", e.rows, e.cols))
+	}
+	return nil
+}
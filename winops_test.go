@@ -0,0 +1,90 @@
+package vtermtest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestWinopsTracker(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   []int
+	}{
+		{
+			name:   "text area size",
+			chunks: []string{"\x1b[18t"},
+			want:   []int{18},
+		},
+		{
+			name:   "pixel size with extra param",
+			chunks: []string{"abc\x1b[14;2t"},
+			want:   []int{14},
+		},
+		{
+			name:   "split across reads",
+			chunks: []string{"\x1b", "[1", "8t"},
+			want:   []int{18},
+		},
+		{
+			name:   "other sequences ignored",
+			chunks: []string{"\x1b[22t\x1b[2J\x1b[?25l\x1b[8;24;80t"},
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var w winopsTracker
+			var got []int
+			for _, c := range tt.chunks {
+				got = append(got, w.feed([]byte(c))...)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWindowSizeReports(t *testing.T) {
+	tests := []struct {
+		name  string
+		emu   *Emulator
+		query string
+		want  string
+	}{
+		{
+			name:  "text area size",
+			emu:   New(6, 40),
+			query: "18",
+			want:  "[8;6;40",
+		},
+		{
+			name:  "derived pixel size",
+			emu:   New(6, 40),
+			query: "14",
+			want:  "[4;96;320",
+		},
+		{
+			name:  "configured pixel size",
+			emu:   New(6, 40).WithPixelSize(800, 600),
+			query: "14",
+			want:  "[4;600;800",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			emu := tt.emu.Command("bash", "-c", `stty raw -echo; printf '\033[`+tt.query+`t'; IFS= read -r -d t reply; printf '%s\r\n' "${reply#?}"`)
+			t.Cleanup(func() { _ = emu.Close() })
+
+			if err := emu.Start(context.Background()); err != nil {
+				t.Fatalf("start: %v", err)
+			}
+
+			emu.AssertLineEqual(t, 0, tt.want)
+		})
+	}
+}